@@ -0,0 +1,121 @@
+package critbit
+
+const (
+	// The fraction of the arena's heap that may be garbage before the
+	// heap is compacted, if WithKeyArena is given a ratio of zero.
+	kDefaultArenaGarbageRatio = 0.5
+
+	// Don't bother compacting until there is at least this much garbage.
+	kMinArenaGarbage = 4096
+)
+
+// WithKeyArena makes the tree store all of its keys in one contiguous
+// byte heap, instead of keeping a separate string for each key. A tree
+// with many keys then has far fewer objects for the garbage collector
+// to scan. The bytes of deleted keys are garbage until the heap is
+// compacted, which happens when the heap grows, or when the garbage
+// exceeds garbageRatio (between 0 and 1) of the heap. A garbageRatio of
+// 0 or less, or NaN, uses the default ratio of 0.5. A garbageRatio of
+// more than 1 is clamped to 1, so the heap is only compacted when it
+// grows, since the garbage can't exceed it. Trees that are split from an
+// arena tree get their own compacted arenas.
+//
+// The keys which the tree returns share the heap's memory, so holding
+// on to one of them keeps the heap it was returned from alive.
+func WithKeyArena(garbageRatio float64) Option {
+	return func(cfg *config) {
+		cfg.keyArena = true
+		cfg.arenaGarbageRatio = clampGarbageRatio(garbageRatio)
+	}
+}
+
+// A keyArena holds the keys of all the external refs in one heap. In
+// place of its own string, each ref's key is a string which points into
+// the heap, so a ref in an arena tree is no bigger than in any other
+// tree. If the tree has a collation, the original key follows the sort
+// key in the heap, and the originalKeys array points to it.
+type keyArena struct {
+	heap         []byte
+	garbage      int // bytes in heap which belong to deleted keys
+	garbageRatio float64
+}
+
+// Returns the garbage ratio to use, for the ratio given to WithKeyArena
+func clampGarbageRatio(garbageRatio float64) float64 {
+	// NaN fails every comparison, so it must fail this one
+	if !(garbageRatio > 0) {
+		return kDefaultArenaGarbageRatio
+	}
+	return min(garbageRatio, 1)
+}
+
+func newKeyArena(garbageRatio float64) *keyArena {
+	return &keyArena{
+		garbageRatio: clampGarbageRatio(garbageRatio),
+	}
+}
+
+// Returns the n bytes of the heap at start, as a string. An empty string
+// doesn't point into the heap, so it doesn't keep an old heap alive.
+func (arena *keyArena) string(start int, n int) string {
+	if n == 0 {
+		return ""
+	}
+	return bytesToString(arena.heap[start : start+n])
+}
+
+// Copies a key, and its original if the tree has a collation, into the
+// heap, and returns the copies. The bytes of a key are never overwritten
+// once they are in the heap; when the heap is full, the keys are copied
+// to a new one. So, the strings returned here remain valid even after
+// the key is deleted or the heap is compacted.
+func (tree *Critbit[T]) addArenaKey(key string, original string) (string, string) {
+	arena := tree.arena
+	size := len(key) + len(original)
+	if len(arena.heap)+size > cap(arena.heap) {
+		// Growing the heap in place would leave the refs pointing to
+		// the old heap, so the keys are moved to the new one
+		live := len(arena.heap) - arena.garbage
+		tree.compactArena(2 * (live + size))
+	}
+	return arena.append(key, original)
+}
+
+// Appends a key and its original to the heap, which must have room for
+// them, and returns the copies.
+func (arena *keyArena) append(key string, original string) (string, string) {
+	start := len(arena.heap)
+	arena.heap = append(arena.heap, key...)
+	arena.heap = append(arena.heap, original...)
+	return arena.string(start, len(key)), arena.string(start+len(key), len(original))
+}
+
+// Counts the size of a deleted key, and its original, as garbage, and
+// compacts the heap if there is too much of it.
+func (tree *Critbit[T]) removeArenaKey(size int) {
+	arena := tree.arena
+	arena.garbage += size
+	if arena.garbage >= kMinArenaGarbage &&
+		float64(arena.garbage) > arena.garbageRatio*float64(len(arena.heap)) {
+		tree.compactArena(len(arena.heap) - arena.garbage)
+	}
+}
+
+// compactArena copies the keys of the live refs to a new heap, of the
+// given capacity, and points the refs to them. The keys of deleted refs
+// are empty, so they don't need to be skipped.
+func (tree *Critbit[T]) compactArena(capacity int) {
+	arena := tree.arena
+	arena.heap = make([]byte, 0, capacity)
+	for i := range tree.externalRefs {
+		ref := &tree.externalRefs[i]
+		// The ref which is being added may not have its original key
+		// in the array yet
+		if i < len(tree.originalKeys) {
+			ref.key, tree.originalKeys[i] = arena.append(ref.key, tree.originalKeys[i])
+		} else {
+			ref.key, _ = arena.append(ref.key, "")
+		}
+	}
+	arena.garbage = 0
+}
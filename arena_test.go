@@ -0,0 +1,162 @@
+package critbit
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestTotalStringSize(c *C) {
+	for _, tree := range []*Critbit[int]{New[int](0), New[int](0, WithKeyArena(0))} {
+		c.Check(tree.TotalStringSize(), Equals, 0)
+		tree.Insert("red", 1)
+		tree.Insert("green", 2)
+		tree.Insert("green", 3)
+		c.Check(tree.TotalStringSize(), Equals, 8)
+		tree.Upsert("blue", 4)
+		c.Check(tree.TotalStringSize(), Equals, 12)
+		tree.Delete("red")
+		tree.Delete("yellow")
+		c.Check(tree.TotalStringSize(), Equals, 9)
+		tree.Delete("green")
+		tree.Delete("blue")
+		c.Check(tree.TotalStringSize(), Equals, 0)
+	}
+}
+
+// Checks that every key of the tree is in its arena's heap, rather than
+// in its own string or in an old heap
+func checkKeysInArena(c *C, tree *Critbit[int]) {
+	heap := tree.arena.heap
+	for i := range tree.externalRefs {
+		keys := []string{tree.externalRefs[i].key}
+		if tree.originalKeys != nil {
+			keys = append(keys, tree.originalKeys[i])
+		}
+		for _, key := range keys {
+			if key == "" {
+				continue
+			}
+			offset := uintptr(unsafe.Pointer(unsafe.StringData(key))) -
+				uintptr(unsafe.Pointer(unsafe.SliceData(heap)))
+			c.Check(offset+uintptr(len(key)) <= uintptr(len(heap)), Equals, true, Commentf("%q", key))
+		}
+	}
+}
+
+func (s *MySuite) TestKeyArena(c *C) {
+	tree := New[int](0, WithKeyArena(0))
+	table := []string{"zoo", "green", "green boy", "gremlin", "gray", "", "apple"}
+	for i, key := range table {
+		ok, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+		c.Check(ok, Equals, true)
+	}
+	c.Check(tree.Keys(), DeepEquals,
+		[]string{"", "apple", "gray", "green", "green boy", "gremlin", "zoo"})
+	for i, key := range table {
+		value, has := tree.Get(key)
+		c.Check(has, Equals, true)
+		c.Check(value, Equals, i)
+	}
+
+	// No key is kept as its own string
+	checkKeysInArena(c, tree)
+
+	// A deleted ref is reused
+	c.Check(tree.Delete("green"), Equals, true)
+	ok, err := tree.Insert("blue", 7)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, true)
+	c.Check(tree.Keys(), DeepEquals,
+		[]string{"", "apple", "blue", "gray", "green boy", "gremlin", "zoo"})
+	c.Check(tree.externalRefs, HasLen, len(table))
+	checkKeysInArena(c, tree)
+}
+
+func (s *MySuite) TestKeyArenaCompaction(c *C) {
+	tree := New[int](0, WithKeyArena(0.5))
+	for i := 0; i < 1000; i++ {
+		tree.Insert(fmt.Sprintf("key-%012d", i), i)
+	}
+	c.Check(len(tree.arena.heap), Equals, 16000)
+	// Growing the heap moved the keys to the new heap
+	checkKeysInArena(c, tree)
+
+	// Keys handed out before a compaction stay intact
+	before := tree.Keys()
+
+	for i := 0; i < 1000; i += 3 {
+		c.Check(tree.Delete(fmt.Sprintf("key-%012d", i)), Equals, true)
+	}
+	// Not enough garbage yet
+	c.Check(len(tree.arena.heap), Equals, 16000)
+	for i := 1; i < 1000; i += 3 {
+		c.Check(tree.Delete(fmt.Sprintf("key-%012d", i)), Equals, true)
+	}
+	c.Check(len(tree.arena.heap) < 16000, Equals, true)
+	c.Check(len(tree.arena.heap)-tree.arena.garbage, Equals, tree.TotalStringSize())
+	checkKeysInArena(c, tree)
+
+	c.Check(before[0], Equals, "key-000000000000")
+	c.Check(before[999], Equals, "key-000000000999")
+	keys := tree.Keys()
+	c.Check(len(keys), Equals, 333)
+	for i, key := range keys {
+		c.Check(key, Equals, fmt.Sprintf("key-%012d", 3*i+2))
+		value, has := tree.Get(key)
+		c.Check(has, Equals, true)
+		c.Check(value, Equals, 3*i+2)
+	}
+}
+
+func (s *MySuite) TestKeyArenaSplit(c *C) {
+	tree := New[int](0, WithKeyArena(0))
+	tree.Insert("a", 1)
+	tree.Insert("b", 2)
+	left, right := tree.Split()
+	c.Check(left.Keys(), DeepEquals, []string{"a"})
+	c.Check(right.Keys(), DeepEquals, []string{"b"})
+	c.Check(left.arena, NotNil)
+	c.Check(right.arena, NotNil)
+	c.Check(left.TotalStringSize(), Equals, 1)
+
+	tree.Insert("c", 3)
+	tree.Insert("d", 4)
+	tree.Delete("a")
+	left, right = tree.Split()
+	c.Check(left.Keys(), DeepEquals, []string{"b"})
+	c.Check(right.Keys(), DeepEquals, []string{"c", "d"})
+	c.Check(string(right.arena.heap), Equals, "cd")
+	c.Check(right.TotalStringSize(), Equals, 2)
+}
+
+func (s *MySuite) TestKeyArenaGarbageRatio(c *C) {
+	for _, test := range []struct {
+		given, used float64
+	}{
+		{0, kDefaultArenaGarbageRatio},
+		{-1, kDefaultArenaGarbageRatio},
+		{math.NaN(), kDefaultArenaGarbageRatio},
+		{0.25, 0.25},
+		{1, 1},
+		{math.Inf(1), 1},
+	} {
+		tree := New[int](0, WithKeyArena(test.given))
+		c.Check(tree.arena.garbageRatio, Equals, test.used, Commentf("%v", test.given))
+	}
+
+	// With a NaN ratio, the heap is still compacted
+	tree := New[int](0, WithKeyArena(math.NaN()))
+	for i := 0; i < 1000; i++ {
+		_, err := tree.Insert(fmt.Sprintf("key %d", i), i)
+		c.Assert(err, IsNil)
+	}
+	for i := 0; i < 900; i++ {
+		tree.Delete(fmt.Sprintf("key %d", i))
+	}
+	c.Check(tree.arena.garbage < kMinArenaGarbage, Equals, true)
+}
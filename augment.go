@@ -0,0 +1,58 @@
+package critbit
+
+// The tree can keep a summary of each internal node's subtree, in arrays
+// parallel to internalNodes: the maximum score under the node, for
+// TopKWithPrefix, and the number of keys under it, for RandomKey and
+// Sample. The scores are allocated when the first score is set, and the
+// sizes if the tree was created with WithSubtreeSizes.
+// After an insertion or a deletion, the only nodes whose summaries can
+// have changed are those on the path to the key.
+
+func (tree *Critbit[T]) hasAugments() bool {
+	return tree.scores != nil || tree.subtreeSizes != nil
+}
+
+// Recomputes the summaries of the nodes on the path to a key of keyBits
+// bits, from the bottom up.
+func (tree *Critbit[T]) refreshAugments(key string, keyBits int64) {
+	if !tree.hasAugments() || tree.rootItemType() != kChildIntNode {
+		return
+	}
+	var path []nodeIndex
+	nodeNum := tree.rootItem
+	for {
+		path = append(path, nodeNum)
+		node := &tree.internalNodes[nodeNum]
+		direction := node.direction(key, keyBits)
+		if node.getChildType(direction) != kChildIntNode {
+			break
+		}
+		nodeNum = node.child[direction]
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		tree.refreshNodeAugments(path[i])
+	}
+}
+
+// Recomputes the summaries of all the nodes under an item
+func (tree *Critbit[T]) refreshAllAugments(itemType byte, itemID nodeIndex) {
+	if !tree.hasAugments() || itemType != kChildIntNode {
+		return
+	}
+	node := &tree.internalNodes[itemID]
+	tree.refreshAllAugments(node.getChildType(kDirectionLeft), node.child[kDirectionLeft])
+	tree.refreshAllAugments(node.getChildType(kDirectionRight), node.child[kDirectionRight])
+	tree.refreshNodeAugments(itemID)
+}
+
+// Recomputes the summaries of a node from those of its children
+func (tree *Critbit[T]) refreshNodeAugments(nodeNum nodeIndex) {
+	if tree.scores != nil {
+		tree.refreshMaxScore(nodeNum)
+	}
+	if tree.subtreeSizes != nil {
+		node := &tree.internalNodes[nodeNum]
+		tree.subtreeSizes[nodeNum] = tree.childSubtreeSize(node, kDirectionLeft) +
+			tree.childSubtreeSize(node, kDirectionRight)
+	}
+}
@@ -0,0 +1,137 @@
+package critbit
+
+import (
+	"iter"
+	"runtime"
+	"testing"
+
+	"github.com/gilramir/critbit/internal/benchdata"
+)
+
+// The number of keys in each benchmark's tree
+const benchNumKeys = 10000
+
+// Runs a benchmark for each dataset
+func benchDatasets(b *testing.B, bench func(b *testing.B, keys []string)) {
+	for _, dataset := range benchdata.Datasets {
+		keys := dataset.Keys(benchNumKeys)
+		b.Run(dataset.Name, func(b *testing.B) {
+			bench(b, keys)
+		})
+	}
+}
+
+func benchTree(b *testing.B, keys []string) *Critbit[int] {
+	tree := New[int](len(keys))
+	for i, key := range keys {
+		if _, err := tree.Insert(key, i); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return tree
+}
+
+// Returns the bytes on the heap, after collecting garbage
+func heapBytes() uint64 {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func BenchmarkInsert(b *testing.B) {
+	benchDatasets(b, func(b *testing.B, keys []string) {
+		before := heapBytes()
+		tree := benchTree(b, keys)
+		bytesPerKey := float64(heapBytes()-before) / float64(len(keys))
+		runtime.KeepAlive(tree)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			benchTree(b, keys)
+		}
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(keys)), "ns/key")
+		b.ReportMetric(bytesPerKey, "bytes/key")
+	})
+}
+
+func BenchmarkGet(b *testing.B) {
+	benchDatasets(b, func(b *testing.B, keys []string) {
+		tree := benchTree(b, keys)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, found := tree.Get(keys[i%len(keys)]); !found {
+				b.Fatalf("Didn't find %q", keys[i%len(keys)])
+			}
+		}
+	})
+}
+
+func BenchmarkDelete(b *testing.B) {
+	benchDatasets(b, func(b *testing.B, keys []string) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			tree := benchTree(b, keys)
+			b.StartTimer()
+			for _, key := range keys {
+				tree.Delete(key)
+			}
+		}
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(keys)), "ns/key")
+	})
+}
+
+// Returns a benchmark of iterating over a tree with an iterator
+func benchIterate(iterate func(tree *Critbit[int]) iter.Seq2[string, int]) func(*testing.B) {
+	return func(b *testing.B) {
+		benchDatasets(b, func(b *testing.B, keys []string) {
+			tree := benchTree(b, keys)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				n := 0
+				for range iterate(tree) {
+					n++
+				}
+				if n != len(keys) {
+					b.Fatalf("Iterated over %d keys, expected %d", n, len(keys))
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(keys)), "ns/key")
+		})
+	}
+}
+
+// IterateRange walks the tree in the calling goroutine
+func BenchmarkIterate(b *testing.B) {
+	benchIterate(func(tree *Critbit[int]) iter.Seq2[string, int] {
+		return tree.IterateRange("", "")
+	})(b)
+}
+
+// IterateItems walks the tree in another goroutine, and sends the keys
+// over a channel
+func BenchmarkIterateItems(b *testing.B) {
+	benchIterate(func(tree *Critbit[int]) iter.Seq2[string, int] {
+		return tree.IterateItems()
+	})(b)
+}
+
+func BenchmarkSplit(b *testing.B) {
+	benchDatasets(b, func(b *testing.B, keys []string) {
+		tree := benchTree(b, keys)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			tree.Split()
+		}
+	})
+}
+
+func BenchmarkLouds(b *testing.B) {
+	benchDatasets(b, func(b *testing.B, keys []string) {
+		tree := benchTree(b, keys)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			tree.LoudsBits()
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package critbit
+
+import (
+	"iter"
+
+	"github.com/pkg/errors"
+)
+
+// The *Bits methods take keys whose length is any number of bits, such
+// as the prefixes of a routing table or the codes of a Huffman table.
+// A key is given as a byte slice and a length in bits; the bits are
+// packed most significant bit first, and the bits of the last byte past
+// the length are ignored. A key is a prefix of its extensions, so a key
+// and the same key followed by a 0 bit are different keys, and a key
+// sorts before its extensions.
+//
+// The tree stores a bit key as its bytes, with the bits past its length
+// set to 0, and the string methods, like Keys and IterateItems, return a
+// bit key as those bytes. They still compare keys bit by bit, so a bit
+// key is a prefix of the strings which start with its bits, and sorts
+// before the byte key with the same bytes. The tree indexes bit keys as
+// they are, so the *Bits methods can't be used with a collation.
+
+// A BitKey is a key returned by the *Bits methods.
+type BitKey struct {
+	Bytes  string // the bits, packed; the bits past Length are 0
+	Length int    // the number of bits
+}
+
+// Returns the bytes of a bit key, as a string which shares their memory,
+// and whether the key is valid in this tree.
+func (tree *Critbit[T]) bitKey(key []byte, bitLength int) (string, bool) {
+	if tree.config.collation != nil || bitLength < 0 || int64(bitLength) > 8*int64(len(key)) {
+		return "", false
+	}
+	return bytesToString(key[:(bitLength+7)/8]), true
+}
+
+// Returns a copy of the bytes of a bit key, with the bits past its
+// length set to 0, or an error if the key is not valid in this tree.
+func (tree *Critbit[T]) storedBitKey(key []byte, bitLength int) (string, error) {
+	if tree.config.collation != nil {
+		return "", errors.New("Bit keys can't be used with a collation")
+	}
+	if bitLength < 0 || int64(bitLength) > 8*int64(len(key)) {
+		return "", errors.Errorf("Bit length %d is out of range for %d bytes", bitLength, len(key))
+	}
+	stored := make([]byte, (bitLength+7)/8)
+	copy(stored, key)
+	if bitLength%8 != 0 {
+		stored[len(stored)-1] &= 0xff << (8 - bitLength%8)
+	}
+	// Nothing else refers to the copy, so it can become the string
+	return bytesToString(stored), nil
+}
+
+// Returns the BitKey of a ref
+func (tree *Critbit[T]) refBitKey(refNum nodeIndex) BitKey {
+	key := tree.refKey(refNum)
+	return BitKey{Bytes: key, Length: int(tree.refBits(refNum, key))}
+}
+
+// InsertBits is like Insert, but takes a key of bitLength bits. An error
+// is returned if the bit length is out of range, or the tree has a
+// collation.
+func (tree *Critbit[T]) InsertBits(key []byte, bitLength int, value T) (bool, error) {
+	if lookup, ok := tree.bitKey(key, bitLength); ok {
+		if has, _ := tree.findRef(lookup, int64(bitLength)); has {
+			return false, nil
+		}
+	}
+	stored, err := tree.storedBitKey(key, bitLength)
+	if err != nil {
+		return false, err
+	}
+	return tree.insert(stored, int64(bitLength), stored, value)
+}
+
+// UpsertBits is like Upsert, but takes a key of bitLength bits. An error
+// is returned if the bit length is out of range, or the tree has a
+// collation.
+func (tree *Critbit[T]) UpsertBits(key []byte, bitLength int, value T) error {
+	if tree.UpdateBits(key, bitLength, value) {
+		return nil
+	}
+	stored, err := tree.storedBitKey(key, bitLength)
+	if err != nil {
+		return err
+	}
+	inserted, err := tree.insert(stored, int64(bitLength), stored, value)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		panic("Insert should have succeeded")
+	}
+	return nil
+}
+
+// UpdateBits is like Update, but takes a key of bitLength bits.
+func (tree *Critbit[T]) UpdateBits(key []byte, bitLength int, value T) bool {
+	lookup, ok := tree.bitKey(key, bitLength)
+	if !ok {
+		return false
+	}
+	has, refNum := tree.findRef(lookup, int64(bitLength))
+	if !has {
+		return false
+	}
+	tree.externalRefs[refNum].value = value
+	return true
+}
+
+// GetBits is like Get, but takes a key of bitLength bits.
+func (tree *Critbit[T]) GetBits(key []byte, bitLength int) (T, bool) {
+	var nilVal T
+	lookup, ok := tree.bitKey(key, bitLength)
+	if !ok {
+		return nilVal, false
+	}
+	has, refNum := tree.findRef(lookup, int64(bitLength))
+	if !has {
+		return nilVal, false
+	}
+	return tree.externalRefs[refNum].value, true
+}
+
+// DeleteBits is like Delete, but takes a key of bitLength bits.
+func (tree *Critbit[T]) DeleteBits(key []byte, bitLength int) bool {
+	lookup, ok := tree.bitKey(key, bitLength)
+	if !ok {
+		return false
+	}
+	return tree.delete(lookup, int64(bitLength))
+}
+
+// LongestPrefixBits finds the longest key in the tree which is a prefix
+// of a key of bitLength bits, or is the key itself, and returns it and
+// its value.
+func (tree *Critbit[T]) LongestPrefixBits(key []byte, bitLength int) (BitKey, T, bool) {
+	var nilVal T
+	lookup, ok := tree.bitKey(key, bitLength)
+	if !ok {
+		return BitKey{}, nilVal, false
+	}
+	refNums := tree.findPrefixRefs(lookup, int64(bitLength))
+	if len(refNums) == 0 {
+		return BitKey{}, nilVal, false
+	}
+	refNum := refNums[len(refNums)-1]
+	return tree.refBitKey(refNum), tree.externalRefs[refNum].value, true
+}
+
+// PrefixesOfBits returns an iterator over the keys in the tree which are
+// prefixes of a key of bitLength bits, including the key itself, and
+// their values, from the shortest to the longest. The key must not be
+// modified while the iterator is in use.
+func (tree *Critbit[T]) PrefixesOfBits(key []byte, bitLength int) iter.Seq2[BitKey, T] {
+	return func(yield func(BitKey, T) bool) {
+		lookup, ok := tree.bitKey(key, bitLength)
+		if !ok {
+			return
+		}
+		for _, refNum := range tree.findPrefixRefs(lookup, int64(bitLength)) {
+			if !yield(tree.refBitKey(refNum), tree.externalRefs[refNum].value) {
+				return
+			}
+		}
+	}
+}
+
+// IteratePrefixBits returns an iterator over the keys which start with a
+// prefix of bitLength bits, including the prefix itself, and their
+// values, in sorted order. The prefix must not be modified while the
+// iterator is in use.
+func (tree *Critbit[T]) IteratePrefixBits(prefix []byte, bitLength int) iter.Seq2[BitKey, T] {
+	return func(yield func(BitKey, T) bool) {
+		lookup, ok := tree.bitKey(prefix, bitLength)
+		if !ok {
+			return
+		}
+		itemType, itemID, found := tree.findPrefixRoot(lookup, int64(bitLength))
+		if !found {
+			return
+		}
+		tree.walkRefs(itemType, itemID, func(refNum nodeIndex) bool {
+			return yield(tree.refBitKey(refNum), tree.externalRefs[refNum].value)
+		})
+	}
+}
+
+// IterateBits returns an iterator over all the keys, with their lengths
+// in bits, and their values, in sorted order.
+func (tree *Critbit[T]) IterateBits() iter.Seq2[BitKey, T] {
+	return func(yield func(BitKey, T) bool) {
+		tree.walkRefs(tree.rootItemType(), tree.rootItem, func(refNum nodeIndex) bool {
+			return yield(tree.refBitKey(refNum), tree.externalRefs[refNum].value)
+		})
+	}
+}
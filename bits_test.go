@@ -0,0 +1,204 @@
+package critbit
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+// Packs a string of '0' and '1' characters into bytes
+func parseBits(s string) ([]byte, int) {
+	b := make([]byte, (len(s)+7)/8)
+	for i := 0; i < len(s); i++ {
+		if s[i] == '1' {
+			b[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return b, len(s)
+}
+
+// Returns the bits of a BitKey as '0' and '1' characters
+func (k BitKey) String() string {
+	var sb strings.Builder
+	for i := 0; i < k.Length; i++ {
+		sb.WriteByte('0' + (k.Bytes[i/8]>>(7-i%8))&1)
+	}
+	return sb.String()
+}
+
+func insertBits(c *C, tree *Critbit[int], s string, value int) bool {
+	b, n := parseBits(s)
+	ok, err := tree.InsertBits(b, n, value)
+	c.Assert(err, IsNil)
+	return ok
+}
+
+func bitKeys(seq func(func(BitKey, int) bool)) []string {
+	var keys []string
+	for key := range seq {
+		keys = append(keys, key.String())
+	}
+	return keys
+}
+
+func (s *MySuite) TestBits(c *C) {
+	tree := New[int](0)
+	// A key and its extensions by 0 bits are all different keys, unlike
+	// the whole-byte keys, which can't tell "1" from "10" or "1000"
+	for i, key := range []string{"1", "", "10", "0", "100", "11", "01", "1000", "10000000", "100000000"} {
+		c.Check(insertBits(c, tree, key, i), Equals, true, Commentf("%s", key))
+	}
+	c.Check(insertBits(c, tree, "100", 20), Equals, false)
+	c.Assert(tree.Validate(), IsNil)
+	c.Check(bitKeys(tree.IterateBits()), DeepEquals,
+		[]string{"", "0", "01", "1", "10", "100", "1000", "10000000", "100000000", "11"})
+
+	// The padding bits of a key are ignored
+	value, found := tree.GetBits([]byte{0x9f}, 3)
+	c.Check(found, Equals, true)
+	c.Check(value, Equals, 4)
+	_, found = tree.GetBits([]byte{0x80}, 5)
+	c.Check(found, Equals, false)
+	_, found = tree.GetBits([]byte{0x80}, 9)
+	c.Check(found, Equals, false)
+
+	// The string methods see a bit key as its bytes
+	value, found = tree.Get("\x80")
+	c.Check(found, Equals, true)
+	c.Check(value, Equals, 8)
+
+	key, value, found := tree.LongestPrefixBits([]byte{0x97}, 8)
+	c.Check(found, Equals, true)
+	c.Check(key, Equals, BitKey{"\x80", 3})
+	c.Check(value, Equals, 4)
+	c.Check(bitKeys(tree.PrefixesOfBits([]byte{0x80, 0x7f}, 12)), DeepEquals,
+		[]string{"", "1", "10", "100", "1000", "10000000", "100000000"})
+	c.Check(bitKeys(tree.IteratePrefixBits([]byte{0x80}, 2)), DeepEquals,
+		[]string{"10", "100", "1000", "10000000", "100000000"})
+	c.Check(bitKeys(tree.IteratePrefixBits([]byte{0x80}, 8)), DeepEquals,
+		[]string{"10000000", "100000000"})
+
+	c.Check(tree.UpdateBits([]byte{0x80}, 2, 30), Equals, true)
+	c.Check(tree.UpsertBits([]byte{0x20}, 3, 31), IsNil)
+	c.Check(tree.DeleteBits([]byte{0x80}, 1), Equals, true)
+	c.Check(tree.DeleteBits([]byte{0x80}, 1), Equals, false)
+	c.Assert(tree.Validate(), IsNil)
+	c.Check(bitKeys(tree.IteratePrefixBits(nil, 0)), DeepEquals,
+		[]string{"", "0", "001", "01", "10", "100", "1000", "10000000", "100000000", "11"})
+
+	_, err := tree.InsertBits([]byte{0}, 9, 0)
+	c.Check(err, ErrorMatches, "Bit length 9 is out of range for 1 bytes")
+	_, err = New[int](0, WithCollation(FoldASCII)).InsertBits([]byte{0}, 1, 0)
+	c.Check(err, ErrorMatches, "Bit keys can't be used with a collation")
+}
+
+func (s *MySuite) TestBitsCopyKey(c *C) {
+	tree := New[int](0)
+	buf := []byte{0xff}
+	_, err := tree.InsertBits(buf, 4, 1)
+	c.Assert(err, IsNil)
+	buf[0] = 0
+	c.Check(bitKeys(tree.IterateBits()), DeepEquals, []string{"1111"})
+	c.Check(tree.Keys(), DeepEquals, []string{"\xf0"})
+}
+
+func (s *MySuite) TestBitsRandom(c *C) {
+	checkBitsRandom(c)
+	checkBitsRandom(c, WithKeyArena(0.5))
+	checkBitsRandom(c, WithSubtreeSizes())
+}
+
+// Checks the bit methods against a set of '0' and '1' strings
+func checkBitsRandom(c *C, options ...Option) {
+	rng := rand.New(rand.NewSource(37))
+	randomBits := func() string {
+		b := make([]byte, rng.Intn(20))
+		for i := range b {
+			// Mostly 1s, so that keys share long prefixes and whole
+			// bytes of them
+			b[i] = "0111"[rng.Intn(4)]
+		}
+		return string(b)
+	}
+
+	tree := New[int](0, options...)
+	model := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		key := randomBits()
+		b, n := parseBits(key)
+		switch rng.Intn(3) {
+		case 0, 1:
+			c.Assert(tree.UpsertBits(b, n, i), IsNil)
+			model[key] = i
+		case 2:
+			_, inModel := model[key]
+			c.Assert(tree.DeleteBits(b, n), Equals, inModel, Commentf("%s", key))
+			delete(model, key)
+		}
+	}
+	c.Assert(tree.Validate(), IsNil)
+
+	var expected []string
+	for key := range model {
+		expected = append(expected, key)
+	}
+	sort.Strings(expected)
+	c.Assert(bitKeys(tree.IterateBits()), DeepEquals, expected)
+
+	for i := 0; i < 200; i++ {
+		query := randomBits()
+		b, n := parseBits(query)
+		var prefixes, extensions []string
+		for _, key := range expected {
+			if strings.HasPrefix(query, key) {
+				prefixes = append(prefixes, key)
+			}
+			if strings.HasPrefix(key, query) {
+				extensions = append(extensions, key)
+			}
+		}
+		c.Check(bitKeys(tree.PrefixesOfBits(b, n)), DeepEquals, prefixes, Commentf("%s", query))
+		c.Check(bitKeys(tree.IteratePrefixBits(b, n)), DeepEquals, extensions, Commentf("%s", query))
+		key, _, found := tree.LongestPrefixBits(b, n)
+		c.Check(found, Equals, prefixes != nil)
+		if found {
+			c.Check(key.String(), Equals, prefixes[len(prefixes)-1])
+		}
+		value, found := tree.GetBits(b, n)
+		expectedValue, inModel := model[query]
+		c.Check(found, Equals, inModel)
+		c.Check(value, Equals, expectedValue)
+	}
+
+	// The bit lengths survive splits and the succinct encoding
+	left, right := tree.Split()
+	c.Assert(left.Validate(), IsNil)
+	c.Assert(right.Validate(), IsNil)
+	c.Check(append(bitKeys(left.IterateBits()), bitKeys(right.IterateBits())...), DeepEquals, expected)
+	rebuilt, err := FromLouds(tree.Succinct(), options...)
+	c.Assert(err, IsNil)
+	c.Check(bitKeys(rebuilt.IterateBits()), DeepEquals, expected)
+}
+
+func (s *MySuite) TestBitsMixedWithBytes(c *C) {
+	// Byte keys are bit keys whose length is a whole number of bytes
+	tree := New[int](0)
+	tree.Insert("a", 1)
+	tree.Insert("a\x00", 2)
+	insertBits(c, tree, "011000010", 3)
+	insertBits(c, tree, "0110", 4)
+	c.Assert(tree.Validate(), IsNil)
+	c.Check(bitKeys(tree.IterateBits()), DeepEquals,
+		[]string{"0110", "01100001", "011000010", "0110000100000000"})
+
+	// The range methods see the 9-bit key between "a" and "a\x00"
+	c.Check(seqKeys(tree.IterateRange("a", "a\x00")), DeepEquals, []string{"a", "a\x00"})
+	c.Check(seqKeys(tree.IterateAfter("a", "")), DeepEquals, []string{"a\x00", "a\x00"})
+	c.Check(seqKeys(tree.IteratePrefixAfter("a", "a")), DeepEquals, []string{"a\x00", "a\x00"})
+	// 'b' starts with a 0 bit, so the 9-bit key is a prefix of "ab"
+	c.Check(tree.LongestPrefix("ab").Key, Equals, "a\x00")
+	c.Check(tree.LongestPrefix("a\x80").Key, Equals, "a")
+}
@@ -0,0 +1,151 @@
+package critbit
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// A BitVector is a packed sequence of bits, indexed so that it can answer
+// rank and select queries in O(log n) time. When it holds a LOUDS, as
+// returned by LoudsBits, it can also navigate the tree.
+//
+// In LOUDS, node i, in level order, is represented by the i'th 1 bit
+// (counting from 0), in its parent's degree list; the root's is the fake
+// super-root's. Node i's own degree list, one 1 bit per child and then a
+// 0 bit, follows the i'th 0 bit.
+type BitVector struct {
+	words  []uint64
+	length int
+
+	// ranks[w] is the number of 1 bits before word w
+	ranks []int
+}
+
+func newBitVector(capacity int) *BitVector {
+	return &BitVector{words: make([]uint64, 0, (capacity+63)/64)}
+}
+
+func (bv *BitVector) append(bit bool) {
+	if bv.length%64 == 0 {
+		bv.words = append(bv.words, 0)
+	}
+	if bit {
+		bv.words[bv.length/64] |= 1 << (bv.length % 64)
+	}
+	bv.length++
+}
+
+func (bv *BitVector) buildIndex() {
+	bv.ranks = make([]int, len(bv.words)+1)
+	for w, word := range bv.words {
+		bv.ranks[w+1] = bv.ranks[w] + bits.OnesCount64(word)
+	}
+}
+
+// Len returns the number of bits.
+func (bv *BitVector) Len() int {
+	return bv.length
+}
+
+// Bit returns the bit at position i. It panics if i is not less than
+// Len.
+func (bv *BitVector) Bit(i int) bool {
+	if i < 0 || i >= bv.length {
+		panic(fmt.Sprintf("BitVector.Bit(%d) is out of range; the length is %d", i, bv.length))
+	}
+	return bv.words[i/64]&(1<<(i%64)) != 0
+}
+
+// Bytes returns the bits unpacked, one byte per bit, like a LOUDS.
+func (bv *BitVector) Bytes() []byte {
+	answer := make([]byte, bv.length)
+	for i := range answer {
+		if bv.Bit(i) {
+			answer[i] = 1
+		}
+	}
+	return answer
+}
+
+// Rank1 returns the number of 1 bits before position i. It panics if i
+// is greater than Len.
+func (bv *BitVector) Rank1(i int) int {
+	if i < 0 || i > bv.length {
+		panic(fmt.Sprintf("BitVector.Rank1(%d) is out of range; the length is %d", i, bv.length))
+	}
+	w := i / 64
+	rank := bv.ranks[w]
+	if rem := i % 64; rem > 0 {
+		rank += bits.OnesCount64(bv.words[w] & (1<<rem - 1))
+	}
+	return rank
+}
+
+// Rank0 returns the number of 0 bits before position i. It panics if i
+// is greater than Len.
+func (bv *BitVector) Rank0(i int) int {
+	return i - bv.Rank1(i)
+}
+
+// Select1 returns the position of the k'th 1 bit, counting from 0, so
+// that Rank1(Select1(k)) == k. It returns -1 if there are not that many
+// 1 bits.
+func (bv *BitVector) Select1(k int) int {
+	if k < 0 || k >= bv.ranks[len(bv.words)] {
+		return -1
+	}
+	// The last word with fewer than k+1 1 bits before it
+	w := sort.Search(len(bv.words), func(w int) bool { return bv.ranks[w+1] > k })
+	return 64*w + selectInWord(bv.words[w], k-bv.ranks[w])
+}
+
+// Select0 returns the position of the k'th 0 bit, counting from 0, so
+// that Rank0(Select0(k)) == k. It returns -1 if there are not that many
+// 0 bits.
+func (bv *BitVector) Select0(k int) int {
+	if k < 0 || k >= bv.length-bv.ranks[len(bv.words)] {
+		return -1
+	}
+	zeros := func(w int) int { return 64*w - bv.ranks[w] }
+	w := sort.Search(len(bv.words), func(w int) bool { return zeros(w+1) > k })
+	return 64*w + selectInWord(^bv.words[w], k-zeros(w))
+}
+
+// Returns the position of the k'th 1 bit in a word, which has more than k
+func selectInWord(word uint64, k int) int {
+	for ; k > 0; k-- {
+		word &= word - 1
+	}
+	return bits.TrailingZeros64(word)
+}
+
+// FirstChild returns the level-order number of node i's first child, or
+// -1 if node i is a leaf.
+func (bv *BitVector) FirstChild(i int) int {
+	p := bv.Select0(i) + 1
+	if p == 0 || p >= bv.length || !bv.Bit(p) {
+		return -1
+	}
+	return bv.Rank1(p)
+}
+
+// NextSibling returns the level-order number of node i's next sibling,
+// or -1 if node i is its parent's last child.
+func (bv *BitVector) NextSibling(i int) int {
+	p := bv.Select1(i) + 1
+	if p == 0 || p >= bv.length || !bv.Bit(p) {
+		return -1
+	}
+	return i + 1
+}
+
+// Parent returns the level-order number of node i's parent, or -1 if node
+// i is the root.
+func (bv *BitVector) Parent(i int) int {
+	p := bv.Select1(i)
+	if p == -1 {
+		return -1
+	}
+	return bv.Rank0(p) - 1
+}
@@ -0,0 +1,114 @@
+package critbit
+
+import (
+	"fmt"
+	"math/rand"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestBitVectorRankSelect(c *C) {
+	rng := rand.New(rand.NewSource(1))
+	for _, length := range []int{0, 1, 63, 64, 65, 200, 1000} {
+		louds := make(LOUDS, length)
+		for i := range louds {
+			louds[i] = byte(rng.Intn(2))
+		}
+		bv := louds.BitVector()
+		c.Check(bv.Len(), Equals, length)
+		c.Check(bv.Bytes(), DeepEquals, []byte(louds))
+
+		// Compare with counting
+		ones, zeros := 0, 0
+		for i := 0; i <= length; i++ {
+			comment := Commentf("length %d, i %d", length, i)
+			c.Check(bv.Rank1(i), Equals, ones, comment)
+			c.Check(bv.Rank0(i), Equals, zeros, comment)
+			if i == length {
+				break
+			}
+			if louds[i] == 1 {
+				c.Check(bv.Select1(ones), Equals, i, comment)
+				ones++
+			} else {
+				c.Check(bv.Select0(zeros), Equals, i, comment)
+				zeros++
+			}
+		}
+		c.Check(bv.Select1(ones), Equals, -1)
+		c.Check(bv.Select0(zeros), Equals, -1)
+		c.Check(bv.Select1(-1), Equals, -1)
+	}
+}
+
+func (s *MySuite) TestLoudsBits(c *C) {
+	tree := New[int](0)
+	c.Check(tree.LoudsBits().Bytes(), DeepEquals, []byte{0})
+	c.Check(tree.LoudsBits().FirstChild(0), Equals, -1)
+
+	_, err := tree.Insert("only", 0)
+	c.Assert(err, IsNil)
+	bv := tree.LoudsBits()
+	c.Check(bv.Bytes(), DeepEquals, []byte{1, 0, 0})
+	c.Check(bv.FirstChild(0), Equals, -1)
+	c.Check(bv.NextSibling(0), Equals, -1)
+	c.Check(bv.Parent(0), Equals, -1)
+
+	for i := 1; i < 300; i++ {
+		_, err := tree.Insert(fmt.Sprintf("%x", i*i), i)
+		c.Assert(err, IsNil)
+	}
+	bv = tree.LoudsBits()
+	c.Check(bv.Bytes(), DeepEquals, tree.Louds().ToBytes())
+	c.Check(bv.Len(), Equals, 2*(tree.numInternalNodes+tree.numExternalRefs)+1)
+
+	// Navigating the BitVector visits the nodes in the same order as
+	// walking the tree itself, from the root down.
+	encoding := tree.Succinct()
+	type navItem struct {
+		louds    int
+		itemType byte
+		itemID   nodeIndex
+	}
+	numNodes, numRefs := 0, 0
+	stack := []navItem{{0, tree.rootItemType(), tree.rootItem}}
+	for len(stack) > 0 {
+		item := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		child := bv.FirstChild(item.louds)
+		if child == -1 {
+			c.Check(item.itemType, Equals, byte(kChildExtRef))
+			numRefs++
+			continue
+		}
+		c.Assert(item.itemType, Equals, byte(kChildIntNode))
+		numNodes++
+		node := &tree.internalNodes[item.itemID]
+		sibling := bv.NextSibling(child)
+		c.Check(sibling, Equals, child+1)
+		c.Check(bv.NextSibling(sibling), Equals, -1)
+		c.Check(bv.Parent(child), Equals, item.louds)
+		c.Check(bv.Parent(sibling), Equals, item.louds)
+		stack = append(stack,
+			navItem{child, node.getChildType(0), node.child[0]},
+			navItem{sibling, node.getChildType(1), node.child[1]})
+	}
+	c.Check(numNodes, Equals, len(encoding.Offsets))
+	c.Check(numRefs, Equals, len(encoding.Keys))
+	c.Check(bv.Parent(0), Equals, -1)
+	c.Check(bv.Parent(numNodes+numRefs), Equals, -1)
+	c.Check(bv.FirstChild(numNodes+numRefs), Equals, -1)
+}
+
+func (s *MySuite) TestBitVectorBounds(c *C) {
+	bv := LOUDS{1, 0, 1}.BitVector()
+	c.Check(bv.Bit(2), Equals, true)
+	// The padding after the last bit can't be read
+	c.Check(func() { bv.Bit(3) }, PanicMatches, `BitVector.Bit\(3\) is out of range; the length is 3`)
+	c.Check(func() { bv.Bit(-1) }, PanicMatches, `BitVector.Bit\(-1\) is out of range.*`)
+	c.Check(bv.Rank1(3), Equals, 2)
+	c.Check(func() { bv.Rank1(4) }, PanicMatches, `BitVector.Rank1\(4\) is out of range; the length is 3`)
+	c.Check(func() { bv.Rank0(64) }, PanicMatches, `BitVector.Rank1\(64\) is out of range.*`)
+}
@@ -0,0 +1,57 @@
+package critbit
+
+import (
+	"unsafe"
+)
+
+// The *Bytes methods accept keys as byte slices, such as keys that come
+// straight from a network buffer. The lookups walk the tree over a
+// string that shares the slice's memory, so they don't allocate, unless
+// the tree has a collation. Only the methods which store a key make a
+// copy of it.
+
+// bytesToString returns a string that shares the memory of b. The
+// string must not be retained past the call that uses it, and b
+// must not be modified while it is in use.
+func bytesToString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// GetBytes is like Get, but takes the key as a byte slice.
+func (tree *Critbit[T]) GetBytes(key []byte) (T, bool) {
+	return tree.Get(bytesToString(key))
+}
+
+// GetHasPrefixBytes is like GetHasPrefix, but takes the key as a byte slice.
+func (tree *Critbit[T]) GetHasPrefixBytes(key []byte) *KeyValueTuple[T] {
+	return tree.GetHasPrefix(bytesToString(key))
+}
+
+// InsertBytes is like Insert, but takes the key as a byte slice.
+// The key is copied only if it is inserted.
+func (tree *Critbit[T]) InsertBytes(key []byte, value T) (bool, error) {
+	sortKey := tree.collate(bytesToString(key))
+	if has, _ := tree.findRef(sortKey, bitLen(sortKey)); has {
+		return false, nil
+	}
+	return tree.Insert(string(key), value)
+}
+
+// UpdateBytes is like Update, but takes the key as a byte slice.
+func (tree *Critbit[T]) UpdateBytes(key []byte, value T) bool {
+	return tree.Update(bytesToString(key), value)
+}
+
+// UpsertBytes is like Upsert, but takes the key as a byte slice.
+// The key is copied only if it is inserted.
+func (tree *Critbit[T]) UpsertBytes(key []byte, value T) error {
+	if tree.UpdateBytes(key, value) {
+		return nil
+	}
+	return tree.Upsert(string(key), value)
+}
+
+// DeleteBytes is like Delete, but takes the key as a byte slice.
+func (tree *Critbit[T]) DeleteBytes(key []byte) bool {
+	return tree.Delete(bytesToString(key))
+}
@@ -0,0 +1,63 @@
+package critbit
+
+import (
+	"testing"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestBytes(c *C) {
+	tree := New[int](0)
+	buf := []byte("green")
+
+	ok, err := tree.InsertBytes(buf, 1)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, true)
+	ok, err = tree.InsertBytes([]byte("gray"), 2)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, true)
+	ok, err = tree.InsertBytes([]byte("gray"), 3)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, false)
+
+	// The stored key must be a copy, not share the caller's buffer
+	copy(buf, "xxxxx")
+	c.Check(tree.Keys(), DeepEquals, []string{"gray", "green"})
+
+	value, has := tree.GetBytes([]byte("gray"))
+	c.Check(has, Equals, true)
+	c.Check(value, Equals, 2)
+	_, has = tree.GetBytes([]byte("gr"))
+	c.Check(has, Equals, false)
+
+	kvt := tree.GetHasPrefixBytes([]byte("gre"))
+	c.Assert(kvt, NotNil)
+	c.Check(kvt.Key, Equals, "green")
+
+	c.Check(tree.UpdateBytes([]byte("gray"), 4), Equals, true)
+	c.Check(tree.UpdateBytes([]byte("blue"), 4), Equals, false)
+	c.Check(tree.UpsertBytes([]byte("blue"), 5), IsNil)
+	c.Check(tree.UpsertBytes([]byte("blue"), 6), IsNil)
+	value, _ = tree.Get("blue")
+	c.Check(value, Equals, 6)
+
+	c.Check(tree.DeleteBytes([]byte("gray")), Equals, true)
+	c.Check(tree.DeleteBytes([]byte("gray")), Equals, false)
+	c.Check(tree.Keys(), DeepEquals, []string{"blue", "green"})
+}
+
+func (s *MySuite) TestBytesDoNotAllocate(c *C) {
+	tree := New[int](0)
+	for i, key := range []string{"zoo", "green", "gremlin", "gray", "gas"} {
+		tree.Insert(key, i)
+	}
+	key := []byte("gremlin")
+	allocs := testing.AllocsPerRun(100, func() {
+		tree.GetBytes(key)
+		tree.UpdateBytes(key, 1)
+		tree.InsertBytes(key, 1)
+		tree.UpsertBytes(key, 1)
+	})
+	c.Check(allocs, Equals, float64(0))
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/gilramir/critbit"
+)
+
+// An index is an ordered map from strings to ints, which the benchmarks
+// compare.
+type index interface {
+	Insert(key string, value int)
+	Get(key string) (int, bool)
+	Delete(key string)
+	// Ascend calls fn for each key in order, until fn returns false
+	Ascend(fn func(key string, value int) bool)
+	// SplitAt returns two new indexes, with the first n keys and the rest
+	SplitAt(n int) (index, index)
+	Len() int
+}
+
+// An implementation is a named index constructor
+type implementation struct {
+	name     string
+	newIndex func(capacity int) index
+}
+
+var implementations = []implementation{
+	{"critbit", func(capacity int) index { return &critbitIndex{critbit.New[int](capacity)} }},
+	{"critbit-arena", func(capacity int) index {
+		return &critbitIndex{critbit.New[int](capacity, critbit.WithKeyArena(0))}
+	}},
+	{"map", func(capacity int) index { return newSortedMap(capacity) }},
+	{"btree", func(int) index { return &btree{} }},
+}
+
+type critbitIndex struct {
+	tree *critbit.Critbit[int]
+}
+
+func (c *critbitIndex) Insert(key string, value int) {
+	_ = c.tree.Upsert(key, value)
+}
+
+func (c *critbitIndex) Get(key string) (int, bool) {
+	return c.tree.Get(key)
+}
+
+func (c *critbitIndex) Delete(key string) {
+	c.tree.Delete(key)
+}
+
+func (c *critbitIndex) Ascend(fn func(key string, value int) bool) {
+	// IterateRange walks the tree in this goroutine; IterateItems would
+	// add a channel send per key
+	for key, value := range c.tree.IterateRange("", "") {
+		if !fn(key, value) {
+			return
+		}
+	}
+}
+
+func (c *critbitIndex) SplitAt(n int) (index, index) {
+	left, right := c.tree.SplitAt(n)
+	return &critbitIndex{left}, &critbitIndex{right}
+}
+
+func (c *critbitIndex) Len() int {
+	return c.tree.Length()
+}
+
+// A sortedMap is a map, for lookups, plus a slice of its keys, which is
+// sorted when it is next needed in order. This is how ordered maps are
+// often improvised in Go. The benchmarks charge the sort to inserting the
+// keys; see build.
+type sortedMap struct {
+	values map[string]int
+	keys   []string
+	sorted bool // whether keys holds the map's keys in order
+}
+
+func newSortedMap(capacity int) *sortedMap {
+	return &sortedMap{values: make(map[string]int, capacity), sorted: true}
+}
+
+func (m *sortedMap) Insert(key string, value int) {
+	if _, found := m.values[key]; !found {
+		m.sorted = false
+	}
+	m.values[key] = value
+}
+
+func (m *sortedMap) Get(key string) (int, bool) {
+	value, found := m.values[key]
+	return value, found
+}
+
+func (m *sortedMap) Delete(key string) {
+	if _, found := m.values[key]; found {
+		delete(m.values, key)
+		m.sorted = false
+	}
+}
+
+func (m *sortedMap) sortKeys() {
+	if !m.sorted {
+		m.keys = slices.Sorted(maps.Keys(m.values))
+		m.sorted = true
+	}
+}
+
+func (m *sortedMap) Ascend(fn func(key string, value int) bool) {
+	m.sortKeys()
+	for _, key := range m.keys {
+		if !fn(key, m.values[key]) {
+			return
+		}
+	}
+}
+
+func (m *sortedMap) SplitAt(n int) (index, index) {
+	m.sortKeys()
+	n = max(0, min(n, len(m.keys)))
+	left, right := newSortedMap(n), newSortedMap(len(m.keys)-n)
+	for i, key := range m.keys {
+		if i < n {
+			left.values[key] = m.values[key]
+		} else {
+			right.values[key] = m.values[key]
+		}
+	}
+	left.keys = slices.Clone(m.keys[:n])
+	right.keys = slices.Clone(m.keys[n:])
+	return left, right
+}
+
+func (m *sortedMap) Len() int {
+	return len(m.values)
+}
+
+// btreeLeafSize is the most keys a btree leaf holds
+const btreeLeafSize = 128
+
+// A btree is a two-level B+tree: a sorted array of leaves, each of which
+// holds up to btreeLeafSize sorted keys. Lookups binary-search the leaves'
+// first keys, and then a leaf. Full leaves are split in half, and empty
+// leaves are removed. It stands in for the B-tree libraries which are
+// common alternatives to a critbit tree.
+type btree struct {
+	leaves []*btreeLeaf
+	length int
+}
+
+type btreeLeaf struct {
+	keys   []string
+	values []int
+}
+
+// Returns the index of the leaf which holds, or would hold, the key
+func (t *btree) findLeaf(key string) int {
+	i := sort.Search(len(t.leaves), func(i int) bool { return t.leaves[i].keys[0] > key })
+	return max(0, i-1)
+}
+
+func (t *btree) Insert(key string, value int) {
+	if len(t.leaves) == 0 {
+		t.leaves = []*btreeLeaf{{keys: []string{key}, values: []int{value}}}
+		t.length++
+		return
+	}
+	i := t.findLeaf(key)
+	leaf := t.leaves[i]
+	j, found := slices.BinarySearch(leaf.keys, key)
+	if found {
+		leaf.values[j] = value
+		return
+	}
+	leaf.keys = slices.Insert(leaf.keys, j, key)
+	leaf.values = slices.Insert(leaf.values, j, value)
+	t.length++
+	if len(leaf.keys) > btreeLeafSize {
+		half := len(leaf.keys) / 2
+		right := &btreeLeaf{
+			keys:   slices.Clone(leaf.keys[half:]),
+			values: slices.Clone(leaf.values[half:]),
+		}
+		leaf.keys = slices.Clip(leaf.keys[:half])
+		leaf.values = slices.Clip(leaf.values[:half])
+		t.leaves = slices.Insert(t.leaves, i+1, right)
+	}
+}
+
+func (t *btree) Get(key string) (int, bool) {
+	if len(t.leaves) == 0 {
+		return 0, false
+	}
+	leaf := t.leaves[t.findLeaf(key)]
+	if j, found := slices.BinarySearch(leaf.keys, key); found {
+		return leaf.values[j], true
+	}
+	return 0, false
+}
+
+func (t *btree) Delete(key string) {
+	if len(t.leaves) == 0 {
+		return
+	}
+	i := t.findLeaf(key)
+	leaf := t.leaves[i]
+	j, found := slices.BinarySearch(leaf.keys, key)
+	if !found {
+		return
+	}
+	leaf.keys = slices.Delete(leaf.keys, j, j+1)
+	leaf.values = slices.Delete(leaf.values, j, j+1)
+	t.length--
+	if len(leaf.keys) == 0 {
+		t.leaves = slices.Delete(t.leaves, i, i+1)
+	}
+}
+
+func (t *btree) Ascend(fn func(key string, value int) bool) {
+	for _, leaf := range t.leaves {
+		for j, key := range leaf.keys {
+			if !fn(key, leaf.values[j]) {
+				return
+			}
+		}
+	}
+}
+
+func (t *btree) SplitAt(n int) (index, index) {
+	left, right := &btree{}, &btree{}
+	for _, leaf := range t.leaves {
+		// The keys of the leaf which go to the left tree
+		m := max(0, min(n-left.length, len(leaf.keys)))
+		if m > 0 {
+			left.leaves = append(left.leaves, &btreeLeaf{
+				keys:   slices.Clone(leaf.keys[:m]),
+				values: slices.Clone(leaf.values[:m]),
+			})
+			left.length += m
+		}
+		if m < len(leaf.keys) {
+			right.leaves = append(right.leaves, &btreeLeaf{
+				keys:   slices.Clone(leaf.keys[m:]),
+				values: slices.Clone(leaf.values[m:]),
+			})
+			right.length += len(leaf.keys) - m
+		}
+	}
+	return left, right
+}
+
+func (t *btree) Len() int {
+	return t.length
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func ascendAll(idx index) []string {
+	var items []string
+	idx.Ascend(func(key string, value int) bool {
+		items = append(items, fmt.Sprintf("%s=%d", key, value))
+		return true
+	})
+	return items
+}
+
+// The baselines must agree with the critbit tree, or the comparison
+// is meaningless
+func (s *MySuite) TestBaselinesAgree(c *C) {
+	rng := rand.New(rand.NewSource(1))
+	indexes := make([]index, len(implementations))
+	for i, impl := range implementations {
+		indexes[i] = impl.newIndex(0)
+	}
+	for step := 0; step < 5000; step++ {
+		key := fmt.Sprintf("%x", rng.Intn(1000))
+		deleting := rng.Intn(3) == 0
+		for _, idx := range indexes {
+			if deleting {
+				idx.Delete(key)
+			} else {
+				idx.Insert(key, step)
+			}
+		}
+	}
+
+	want := ascendAll(indexes[0])
+	for i, idx := range indexes[1:] {
+		name := implementations[i+1].name
+		c.Check(idx.Len(), Equals, indexes[0].Len(), Commentf(name))
+		c.Check(ascendAll(idx), DeepEquals, want, Commentf(name))
+		value, found := idx.Get("1f")
+		wantValue, wantFound := indexes[0].Get("1f")
+		c.Check(found, Equals, wantFound, Commentf(name))
+		c.Check(value, Equals, wantValue, Commentf(name))
+
+		for _, n := range []int{-1, 0, 1, len(want) / 3, len(want), len(want) + 1} {
+			left, right := idx.SplitAt(n)
+			c.Check(append(ascendAll(left), ascendAll(right)...), DeepEquals, want, Commentf("%s %d", name, n))
+			c.Check(left.Len(), Equals, max(0, min(n, len(want))), Commentf("%s %d", name, n))
+		}
+	}
+}
+
+func (s *MySuite) TestAscendStops(c *C) {
+	for _, impl := range implementations {
+		idx := impl.newIndex(0)
+		for i, key := range []string{"c", "a", "b"} {
+			idx.Insert(key, i)
+		}
+		var keys []string
+		idx.Ascend(func(key string, value int) bool {
+			keys = append(keys, key)
+			return key != "b"
+		})
+		c.Check(keys, DeepEquals, []string{"a", "b"}, Commentf(impl.name))
+	}
+}
+
+// The map's sort must be done by build, so that it is timed as part of
+// inserting, and its memory is counted
+func (s *MySuite) TestBuildSortsMap(c *C) {
+	impl, ok := findImplementation("map")
+	c.Assert(ok, Equals, true)
+	m := build(impl, []string{"c", "a", "b"}).(*sortedMap)
+	c.Check(m.sorted, Equals, true)
+	c.Check(m.keys, DeepEquals, []string{"a", "b", "c"})
+}
+
+func (s *MySuite) TestRun(c *C) {
+	var buf bytes.Buffer
+	err := run([]string{"-n", "300", "-rounds", "1", "-datasets", "words,ints", "-impls", "critbit,btree"}, &buf)
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	c.Assert(lines, HasLen, 5)
+	c.Check(lines[0], Matches, ` *dataset +index +insert ns/key .* bytes/key`)
+	c.Check(lines[1], Matches, ` *words +critbit( +[0-9.]+){5} +[0-9]+ +[0-9.]+`)
+	c.Check(lines[2], Matches, ` *words +btree( +[0-9.]+){5} +- +[0-9.]+`)
+	c.Check(lines[4], Matches, ` *ints +btree .*`)
+
+	c.Check(run([]string{"-datasets", "nope"}, &buf), ErrorMatches, `Unknown dataset "nope"`)
+	c.Check(run([]string{"-impls", "critbit,skiplist"}, &buf), ErrorMatches, `Unknown index "skiplist"`)
+	c.Check(run([]string{"-n", "0"}, &buf), ErrorMatches, "-n and -rounds must be positive")
+	c.Check(run([]string{"extra"}, &buf), ErrorMatches, `Unexpected argument "extra"`)
+}
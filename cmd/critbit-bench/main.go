@@ -0,0 +1,243 @@
+// Command critbit-bench compares the critbit tree with a map plus a sorted
+// slice, and with a B-tree style index, over the benchmark datasets. For
+// each dataset and index, it reports the time per key to insert, get,
+// delete and iterate over all keys, the time to split the index in half
+// and, for the critbit tree, to build its LOUDS, and the heap bytes per
+// key which the index uses, besides the keys themselves; the critbit-arena
+// index copies the keys into its arena, so its bytes include them. The
+// map's time to insert includes sorting its keys, which it must do before
+// it can be used in order.
+//
+// Usage:
+//
+//	critbit-bench [-n keys] [-rounds n] [-datasets words,urls,uuids,ints] [-impls critbit,critbit-arena,map,btree]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gilramir/critbit/internal/benchdata"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "critbit-bench: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type options struct {
+	numKeys  int
+	rounds   int
+	datasets []benchdata.Dataset
+	impls    []implementation
+}
+
+func parseArgs(args []string) (*options, error) {
+	flags := flag.NewFlagSet("critbit-bench", flag.ContinueOnError)
+	numKeys := flags.Int("n", 100000, "The number of keys in each dataset")
+	rounds := flags.Int("rounds", 3, "The number of times to time each operation; the fastest is reported")
+	datasetNames := flags.String("datasets", "words,urls,uuids,ints", "The datasets to use")
+	implNames := flags.String("impls", "critbit,critbit-arena,map,btree", "The indexes to compare")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, errors.Errorf("Unexpected argument %q", flags.Arg(0))
+	}
+	if *numKeys < 1 || *rounds < 1 {
+		return nil, errors.Errorf("-n and -rounds must be positive")
+	}
+
+	opts := &options{numKeys: *numKeys, rounds: *rounds}
+	for _, name := range strings.Split(*datasetNames, ",") {
+		dataset, ok := benchdata.Find(name)
+		if !ok {
+			return nil, errors.Errorf("Unknown dataset %q", name)
+		}
+		opts.datasets = append(opts.datasets, dataset)
+	}
+	for _, name := range strings.Split(*implNames, ",") {
+		impl, ok := findImplementation(name)
+		if !ok {
+			return nil, errors.Errorf("Unknown index %q", name)
+		}
+		opts.impls = append(opts.impls, impl)
+	}
+	return opts, nil
+}
+
+func findImplementation(name string) (implementation, bool) {
+	for _, impl := range implementations {
+		if impl.name == name {
+			return impl, true
+		}
+	}
+	return implementation{}, false
+}
+
+func run(args []string, stdout io.Writer) error {
+	opts, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "dataset\tindex\tinsert ns/key\tget ns/key\tdelete ns/key\titerate ns/key\tsplit ns/op\tlouds ns/op\tbytes/key\t\n")
+	for _, dataset := range opts.datasets {
+		keys := dataset.Keys(opts.numKeys)
+		for _, impl := range opts.impls {
+			result, err := measure(impl, keys, opts.rounds)
+			if err != nil {
+				return errors.Wrapf(err, "%s, %s", dataset.Name, impl.name)
+			}
+			louds := "-"
+			if result.louds > 0 {
+				louds = fmt.Sprint(result.louds.Nanoseconds())
+			}
+			perKey := func(d time.Duration) string {
+				return fmt.Sprintf("%.1f", float64(d.Nanoseconds())/float64(len(keys)))
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%.1f\t\n",
+				dataset.Name, impl.name, perKey(result.insert), perKey(result.get),
+				perKey(result.delete), perKey(result.iterate), result.split.Nanoseconds(),
+				louds, result.bytesPerKey)
+		}
+	}
+	return w.Flush()
+}
+
+// The times are for all the keys, or for one split or LOUDS
+type results struct {
+	insert, get, delete, iterate, split, louds time.Duration
+	bytesPerKey                                float64
+}
+
+// Returns the fastest of the rounds of f
+func fastest(rounds int, f func() error) (time.Duration, error) {
+	var best time.Duration
+	for i := 0; i < rounds; i++ {
+		start := time.Now()
+		if err := f(); err != nil {
+			return 0, err
+		}
+		if elapsed := time.Since(start); i == 0 || elapsed < best {
+			best = elapsed
+		}
+	}
+	return best, nil
+}
+
+// Returns an index of the keys, ready to be used in order. Starting an
+// iteration makes the map sort its keys, so that the sort is charged to
+// inserting them, and is counted in the heap the index uses, rather than
+// being paid by the first round of iterating and then discarded.
+func build(impl implementation, keys []string) index {
+	idx := impl.newIndex(len(keys))
+	for i, key := range keys {
+		idx.Insert(key, i)
+	}
+	idx.Ascend(func(string, int) bool { return false })
+	return idx
+}
+
+// Returns the bytes on the heap, after collecting garbage
+func heapBytes() uint64 {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func measure(impl implementation, keys []string, rounds int) (*results, error) {
+	result := &results{}
+	var err error
+
+	before := heapBytes()
+	idx := build(impl, keys)
+	result.bytesPerKey = float64(int64(heapBytes())-int64(before)) / float64(len(keys))
+	if idx.Len() != len(keys) {
+		return nil, errors.Errorf("Index has %d keys, expected %d", idx.Len(), len(keys))
+	}
+
+	result.insert, err = fastest(rounds, func() error {
+		build(impl, keys)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.get, err = fastest(rounds, func() error {
+		for _, key := range keys {
+			if _, found := idx.Get(key); !found {
+				return errors.Errorf("Didn't find %q", key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.iterate, err = fastest(rounds, func() error {
+		n := 0
+		idx.Ascend(func(string, int) bool {
+			n++
+			return true
+		})
+		if n != len(keys) {
+			return errors.Errorf("Iterated over %d keys, expected %d", n, len(keys))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.split, err = fastest(rounds, func() error {
+		left, right := idx.SplitAt(len(keys) / 2)
+		if left.Len()+right.Len() != len(keys) {
+			return errors.Errorf("Split into %d and %d keys, expected %d", left.Len(), right.Len(), len(keys))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := idx.(*critbitIndex); ok {
+		result.louds, err = fastest(rounds, func() error {
+			c.tree.LoudsBits()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Deleting empties the index, so each round deletes from a new one,
+	// which isn't timed
+	for i := 0; i < rounds; i++ {
+		idx := build(impl, keys)
+		start := time.Now()
+		for _, key := range keys {
+			idx.Delete(key)
+		}
+		if elapsed := time.Since(start); i == 0 || elapsed < result.delete {
+			result.delete = elapsed
+		}
+		if idx.Len() != 0 {
+			return nil, errors.Errorf("Index has %d keys after deleting them all", idx.Len())
+		}
+	}
+	return result, nil
+}
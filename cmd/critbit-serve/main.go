@@ -0,0 +1,150 @@
+// Command critbit-serve serves a critbit tree over HTTP on localhost, so
+// that other programs on the same host can share it. See the server
+// package for the endpoints. The values are arbitrary JSON.
+//
+// Usage:
+//
+//	critbit-serve [-addr localhost:8080] [-load FILE [-tsv]]
+//
+// The tree starts empty, or with the keys from the file, one per line, or
+// with -tsv, a key, a tab and a value per line. Values from the file are
+// served as JSON strings, and keys without values as null.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/gilramir/critbit"
+	"github.com/gilramir/critbit/server"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "critbit-serve: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	httpServer, listener, err := newServer(args, stdout)
+	if err != nil {
+		return err
+	}
+	return serve(ctx, httpServer, listener)
+}
+
+// Parses the arguments, loads the tree, and listens
+func newServer(args []string, stdout io.Writer) (*http.Server, net.Listener, error) {
+	flags := flag.NewFlagSet("critbit-serve", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:8080", "The loopback address to listen on")
+	load := flags.String("load", "", "A file of keys to load")
+	tsv := flags.Bool("tsv", false, "Each line of the file is a key, a tab, and a value")
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, nil, errors.Errorf("Unexpected argument %q", flags.Arg(0))
+	}
+	if err := checkLoopback(*addr); err != nil {
+		return nil, nil, err
+	}
+
+	tree := critbit.New[json.RawMessage](0)
+	if *load != "" {
+		if err := loadFile(tree, *load, *tsv); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Fprintf(stdout, "Serving %d keys on http://%s\n", tree.Length(), listener.Addr())
+	httpServer := &http.Server{
+		Handler:           server.New(tree),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return httpServer, listener, nil
+}
+
+// Serves until the context is done, and then shuts down gracefully
+func serve(ctx context.Context, httpServer *http.Server, listener net.Listener) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.Serve(listener)
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
+
+// The tree is only for programs on this host, so only loopback addresses
+// are allowed
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return errors.Errorf("Refusing to listen on %s, which isn't a loopback address", addr)
+}
+
+func loadFile(tree *critbit.Critbit[json.RawMessage], filename string, tsv bool) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// A line can't be longer than an int, even if a key can
+	scanner.Buffer(make([]byte, 64*1024), min(critbit.MaxStringLength+1024*1024, math.MaxInt))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		key := line
+		value := json.RawMessage("null")
+		if tsv {
+			var text string
+			var found bool
+			key, text, found = strings.Cut(line, "\t")
+			if !found {
+				return errors.Errorf("%s:%d: No tab between the key and the value", filename, lineNum)
+			}
+			if value, err = json.Marshal(text); err != nil {
+				return err
+			}
+		} else if line == "" {
+			continue
+		}
+		if err := tree.Upsert(key, value); err != nil {
+			return errors.Wrapf(err, "%s:%d", filename, lineNum)
+		}
+	}
+	return errors.Wrapf(scanner.Err(), "Reading %s", filename)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestServe(c *C) {
+	input := filepath.Join(c.MkDir(), "input.tsv")
+	c.Assert(os.WriteFile(input, []byte("apple\tred\nbanana\tyellow\n"), 0o644), IsNil)
+
+	var stdout bytes.Buffer
+	httpServer, listener, err := newServer([]string{"-addr", "127.0.0.1:0", "-load", input, "-tsv"}, &stdout)
+	c.Assert(err, IsNil)
+	baseURL := "http://" + listener.Addr().String()
+	c.Check(stdout.String(), Equals, "Serving 2 keys on "+baseURL+"\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- serve(ctx, httpServer, listener)
+	}()
+
+	resp, err := http.Get(baseURL + "/keys/banana")
+	c.Assert(err, IsNil)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	c.Assert(err, IsNil)
+	c.Check(resp.StatusCode, Equals, http.StatusOK)
+	c.Check(string(body), Equals, `{"key":"banana","value":"yellow"}`+"\n")
+
+	cancel()
+	c.Check(<-done, IsNil)
+}
+
+func (s *MySuite) TestLoadLines(c *C) {
+	input := filepath.Join(c.MkDir(), "input.txt")
+	c.Assert(os.WriteFile(input, []byte("b\n\na\r\n"), 0o644), IsNil)
+
+	var stdout bytes.Buffer
+	_, listener, err := newServer([]string{"-addr", "localhost:0", "-load", input}, &stdout)
+	c.Assert(err, IsNil)
+	listener.Close()
+	c.Check(stdout.String(), Matches, "Serving 2 keys on http://.*\n")
+}
+
+func (s *MySuite) TestArgErrors(c *C) {
+	var stdout bytes.Buffer
+	_, _, err := newServer([]string{"-addr", "0.0.0.0:8080"}, &stdout)
+	c.Check(err, ErrorMatches, "Refusing to listen on 0.0.0.0:8080, which isn't a loopback address")
+	_, _, err = newServer([]string{"-addr", "example.com:80"}, &stdout)
+	c.Check(err, ErrorMatches, "Refusing to listen on example.com:80, .*")
+	_, _, err = newServer([]string{"extra"}, &stdout)
+	c.Check(err, ErrorMatches, `Unexpected argument "extra"`)
+
+	input := filepath.Join(c.MkDir(), "input.tsv")
+	c.Assert(os.WriteFile(input, []byte("a\t1\nb\n"), 0o644), IsNil)
+	_, _, err = newServer([]string{"-addr", "localhost:0", "-load", input, "-tsv"}, &stdout)
+	c.Check(err, ErrorMatches, ".*input.tsv:2: No tab between the key and the value")
+	c.Check(stdout.String(), Equals, "")
+}
+
+func (s *MySuite) TestCheckLoopback(c *C) {
+	c.Check(checkLoopback("[::1]:80"), IsNil)
+	c.Check(checkLoopback("127.0.0.2:80"), IsNil)
+	c.Check(checkLoopback(":80"), NotNil)
+	c.Check(checkLoopback("nonsense"), NotNil)
+}
@@ -0,0 +1,294 @@
+// Command critbit builds critbit trees from text files, saves them to
+// compact tree files, and queries and inspects the saved trees.
+//
+// Usage:
+//
+//	critbit load [-tsv] -o TREEFILE [INPUT...]
+//	critbit get TREEFILE KEY
+//	critbit prefix TREEFILE PREFIX
+//	critbit range TREEFILE START [END]
+//	critbit count TREEFILE [PREFIX]
+//	critbit longest-prefix TREEFILE KEY
+//	critbit stats TREEFILE
+//	critbit dot [-format dot|mermaid|json|ascii] [-values] [-max-depth N] TREEFILE
+//	critbit louds TREEFILE
+//	critbit validate TREEFILE
+//
+// The load command reads one key per line, or with -tsv, a key, a tab and
+// a value per line, from the input files, or from stdin if there are
+// none, or an input is "-". The queries print one key per line, followed
+// by a tab and its value, if it has one.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gilramir/critbit"
+	"github.com/pkg/errors"
+)
+
+const usage = `Usage:
+  critbit load [-tsv] -o TREEFILE [INPUT...]
+  critbit get TREEFILE KEY
+  critbit prefix TREEFILE PREFIX
+  critbit range TREEFILE START [END]
+  critbit count TREEFILE [PREFIX]
+  critbit longest-prefix TREEFILE KEY
+  critbit stats TREEFILE
+  critbit dot [-format dot|mermaid|json|ascii] [-values] [-max-depth N] TREEFILE
+  critbit louds TREEFILE
+  critbit validate TREEFILE
+`
+
+// errNotFound is returned when a query finds nothing, so that the
+// command exits with status 1, like grep
+var errNotFound = errors.New("Not found")
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	if err == errNotFound {
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "critbit: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+// A command runs with the arguments after its name
+type command func(args []string, stdin io.Reader, stdout io.Writer) error
+
+var commands = map[string]command{
+	"load":           loadCommand,
+	"get":            getCommand,
+	"prefix":         prefixCommand,
+	"range":          rangeCommand,
+	"count":          countCommand,
+	"longest-prefix": longestPrefixCommand,
+	"stats":          statsCommand,
+	"dot":            dotCommand,
+	"louds":          loudsCommand,
+	"validate":       validateCommand,
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("No command given\n" + usage)
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return errors.Errorf("Unknown command %q\n%s", args[0], usage)
+	}
+	return cmd(args[1:], stdin, stdout)
+}
+
+// Parses a command's flags, checks the number of arguments, and loads
+// the tree file, which is the first argument
+func loadArgs(name string, flags *flag.FlagSet, args []string, minArgs int,
+	maxArgs int) (*critbit.Critbit[string], []string, error) {
+	if flags == nil {
+		flags = flag.NewFlagSet(name, flag.ContinueOnError)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	args = flags.Args()
+	if len(args) < minArgs || len(args) > maxArgs {
+		return nil, nil, errors.Errorf("Wrong number of arguments for %s\n%s", name, usage)
+	}
+	tree, err := loadTree(args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return tree, args[1:], nil
+}
+
+func printItem(w io.Writer, key string, value string) error {
+	var err error
+	if value == "" {
+		_, err = fmt.Fprintf(w, "%s\n", key)
+	} else {
+		_, err = fmt.Fprintf(w, "%s\t%s\n", key, value)
+	}
+	return err
+}
+
+// Prints the items, and returns errNotFound if there are none
+func printItems(w io.Writer, items func(yield func(string, string) bool)) error {
+	found := false
+	var err error
+	for key, value := range items {
+		found = true
+		if err = printItem(w, key, value); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return errNotFound
+	}
+	return nil
+}
+
+func loadCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("load", flag.ContinueOnError)
+	tsv := flags.Bool("tsv", false, "Each line is a key, a tab, and a value")
+	output := flags.String("o", "", "The tree file to write")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *output == "" {
+		return errors.Errorf("No tree file given with -o\n%s", usage)
+	}
+
+	tree := critbit.New[string](0)
+	inputs := flags.Args()
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+	for _, input := range inputs {
+		if input == "-" {
+			if err := readKeys(tree, stdin, "stdin", *tsv); err != nil {
+				return err
+			}
+			continue
+		}
+		file, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		err = readKeys(tree, file, input, *tsv)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if err := saveTree(tree, *output); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(stdout, "Loaded %d keys into %s\n", tree.Length(), *output)
+	return err
+}
+
+func getCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	tree, args, err := loadArgs("get", nil, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	value, found := tree.Get(args[0])
+	if !found {
+		return errNotFound
+	}
+	_, err = fmt.Fprintf(stdout, "%s\n", value)
+	return err
+}
+
+func prefixCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	tree, args, err := loadArgs("prefix", nil, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	return printItems(stdout, tree.IteratePrefix(args[0]))
+}
+
+func rangeCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	tree, args, err := loadArgs("range", nil, args, 2, 3)
+	if err != nil {
+		return err
+	}
+	end := ""
+	if len(args) == 2 {
+		end = args[1]
+	}
+	return printItems(stdout, tree.IterateRange(args[0], end))
+}
+
+func countCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	tree, args, err := loadArgs("count", nil, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	count := tree.Length()
+	if len(args) == 1 {
+		count = 0
+		for range tree.IteratePrefix(args[0]) {
+			count++
+		}
+	}
+	_, err = fmt.Fprintf(stdout, "%d\n", count)
+	return err
+}
+
+func longestPrefixCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	tree, args, err := loadArgs("longest-prefix", nil, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	kvt := tree.LongestPrefix(args[0])
+	if kvt == nil {
+		return errNotFound
+	}
+	return printItem(stdout, kvt.Key, kvt.Value)
+}
+
+func statsCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	tree, _, err := loadArgs("stats", nil, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(tree.Stats())
+}
+
+func dotCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("dot", flag.ContinueOnError)
+	format := flags.String("format", "dot", "The format: dot, mermaid, json or ascii")
+	var options critbit.ExportOptions
+	flags.BoolVar(&options.ShowValues, "values", false, "Show the values")
+	flags.IntVar(&options.MaxDepth, "max-depth", 0, "Summarize the subtrees below this depth")
+	tree, _, err := loadArgs("dot", flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(*format) {
+	case "dot":
+		return tree.WriteDot(stdout, options)
+	case "mermaid":
+		return tree.WriteMermaid(stdout, options)
+	case "json":
+		return tree.WriteJSON(stdout, options)
+	case "ascii":
+		return tree.WriteASCII(stdout, options)
+	}
+	return errors.Errorf("Unknown format %q", *format)
+}
+
+func loudsCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	tree, _, err := loadArgs("louds", nil, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, bit := range tree.Louds().ToBytes() {
+		sb.WriteByte('0' + bit)
+	}
+	_, err = fmt.Fprintf(stdout, "%s\n", sb.String())
+	return err
+}
+
+func validateCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	tree, _, err := loadArgs("validate", nil, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if err := tree.Validate(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "OK: %d keys\n", tree.Length())
+	return err
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+// Runs the command, and returns its output
+func runCommand(c *C, stdin string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	err := run(args, strings.NewReader(stdin), &stdout)
+	return stdout.String(), err
+}
+
+// Loads a TSV tree into a temporary file, and returns its name
+func loadTestTree(c *C) string {
+	dir := c.MkDir()
+	input := filepath.Join(dir, "input.tsv")
+	c.Assert(os.WriteFile(input, []byte("apple\t1\napp\t2\r\napricot\t3\nbanana\t4\nband\t5\napple\t6\n"), 0o644), IsNil)
+	treeFile := filepath.Join(dir, "tree.cbt")
+	output, err := runCommand(c, "", "load", "-tsv", "-o", treeFile, input)
+	c.Assert(err, IsNil)
+	c.Check(output, Equals, "Loaded 5 keys into "+treeFile+"\n")
+	return treeFile
+}
+
+func (s *MySuite) TestQueries(c *C) {
+	treeFile := loadTestTree(c)
+
+	output, err := runCommand(c, "", "get", treeFile, "apple")
+	c.Check(err, IsNil)
+	c.Check(output, Equals, "6\n")
+	_, err = runCommand(c, "", "get", treeFile, "ap")
+	c.Check(err, Equals, errNotFound)
+
+	output, err = runCommand(c, "", "prefix", treeFile, "ap")
+	c.Check(err, IsNil)
+	c.Check(output, Equals, "app\t2\napple\t6\napricot\t3\n")
+	_, err = runCommand(c, "", "prefix", treeFile, "c")
+	c.Check(err, Equals, errNotFound)
+
+	output, err = runCommand(c, "", "range", treeFile, "apple", "band")
+	c.Check(err, IsNil)
+	c.Check(output, Equals, "apple\t6\napricot\t3\nbanana\t4\n")
+	output, err = runCommand(c, "", "range", treeFile, "b")
+	c.Check(err, IsNil)
+	c.Check(output, Equals, "banana\t4\nband\t5\n")
+
+	output, err = runCommand(c, "", "count", treeFile)
+	c.Check(err, IsNil)
+	c.Check(output, Equals, "5\n")
+	output, err = runCommand(c, "", "count", treeFile, "ban")
+	c.Check(err, IsNil)
+	c.Check(output, Equals, "2\n")
+
+	output, err = runCommand(c, "", "longest-prefix", treeFile, "applesauce")
+	c.Check(err, IsNil)
+	c.Check(output, Equals, "apple\t6\n")
+	_, err = runCommand(c, "", "longest-prefix", treeFile, "cherry")
+	c.Check(err, Equals, errNotFound)
+}
+
+func (s *MySuite) TestInspection(c *C) {
+	treeFile := loadTestTree(c)
+
+	output, err := runCommand(c, "", "stats", treeFile)
+	c.Check(err, IsNil)
+	c.Check(output, Matches, `(?s)\{\n  "NumKeys": 5,.*`)
+
+	output, err = runCommand(c, "", "dot", "-values", treeFile)
+	c.Check(err, IsNil)
+	c.Check(output, Matches, `(?s)digraph xbtrie_critbit \{.*value=6.*`)
+	output, err = runCommand(c, "", "dot", "-format", "ascii", "-max-depth", "1", treeFile)
+	c.Check(err, IsNil)
+	c.Check(output, Matches, `(?s)off=0 .*── nodeNum=\d+ \(\d keys\)\n.*`)
+	_, err = runCommand(c, "", "dot", "-format", "png", treeFile)
+	c.Check(err, ErrorMatches, `Unknown format "png"`)
+
+	output, err = runCommand(c, "", "louds", treeFile)
+	c.Check(err, IsNil)
+	c.Check(output, Matches, `10[01]{17}\n`)
+
+	output, err = runCommand(c, "", "validate", treeFile)
+	c.Check(err, IsNil)
+	c.Check(output, Equals, "OK: 5 keys\n")
+}
+
+func (s *MySuite) TestLoadLines(c *C) {
+	treeFile := filepath.Join(c.MkDir(), "tree.cbt")
+	output, err := runCommand(c, "b\n\na\nc\na\n", "load", "-o", treeFile)
+	c.Assert(err, IsNil)
+	c.Check(output, Equals, "Loaded 3 keys into "+treeFile+"\n")
+
+	// Keys without values are printed alone
+	output, err = runCommand(c, "", "range", treeFile, "")
+	c.Check(err, IsNil)
+	c.Check(output, Equals, "a\nb\nc\n")
+	output, err = runCommand(c, "", "get", treeFile, "a")
+	c.Check(err, IsNil)
+	c.Check(output, Equals, "\n")
+}
+
+func (s *MySuite) TestErrors(c *C) {
+	dir := c.MkDir()
+	treeFile := filepath.Join(dir, "tree.cbt")
+
+	_, err := runCommand(c, "")
+	c.Check(err, ErrorMatches, "(?s)No command given.*")
+	_, err = runCommand(c, "", "frobnicate")
+	c.Check(err, ErrorMatches, `(?s)Unknown command "frobnicate".*`)
+	_, err = runCommand(c, "", "get", treeFile)
+	c.Check(err, ErrorMatches, "(?s)Wrong number of arguments for get.*")
+	_, err = runCommand(c, "a\n", "load")
+	c.Check(err, ErrorMatches, "(?s)No tree file given with -o.*")
+	_, err = runCommand(c, "a\tb\nc\n", "load", "-tsv", "-o", treeFile)
+	c.Check(err, ErrorMatches, "stdin:2: No tab between the key and the value")
+	_, err = runCommand(c, "", "get", treeFile, "a")
+	c.Check(err, ErrorMatches, ".*no such file or directory")
+
+	notTree := filepath.Join(dir, "not-a-tree")
+	c.Assert(os.WriteFile(notTree, []byte("hello\n"), 0o644), IsNil)
+	_, err = runCommand(c, "", "validate", notTree)
+	c.Check(err, ErrorMatches, "Reading .*not-a-tree: Not a critbit tree file")
+}
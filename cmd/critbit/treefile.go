@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/gob"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/gilramir/critbit"
+	"github.com/pkg/errors"
+)
+
+// treeFileMagic starts every tree file, so that other files are rejected
+// with a clear error
+const treeFileMagic = "critbit tree v1\n"
+
+// Saves a tree to a file: the magic line, and then the tree's
+// SuccinctEncoding, gob-encoded and gzipped. The LOUDS bits, one per
+// byte, compress well.
+func saveTree(tree *critbit.Critbit[string], filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return errors.Wrapf(err, "Opening %s for writing", filename)
+	}
+	err = writeTree(tree, file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	return errors.Wrapf(err, "Writing %s", filename)
+}
+
+func writeTree(tree *critbit.Critbit[string], w io.Writer) error {
+	if _, err := io.WriteString(w, treeFileMagic); err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(w)
+	if err := gob.NewEncoder(zw).Encode(tree.Succinct()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Loads a tree which saveTree saved
+func loadTree(filename string) (*critbit.Critbit[string], error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	tree, err := readTree(bufio.NewReader(file))
+	return tree, errors.Wrapf(err, "Reading %s", filename)
+}
+
+func readTree(r io.Reader) (*critbit.Critbit[string], error) {
+	magic := make([]byte, len(treeFileMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != treeFileMagic {
+		return nil, errors.New("Not a critbit tree file")
+	}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var encoding critbit.SuccinctEncoding[string]
+	if err := gob.NewDecoder(zr).Decode(&encoding); err != nil {
+		return nil, err
+	}
+	return critbit.FromLouds(&encoding)
+}
+
+// Reads keys into a tree, one per line. With tsv, each line is a key, a
+// tab, and a value; otherwise, the whole line is the key, its value is
+// empty, and blank lines are skipped. A key which appears twice gets the
+// later value.
+func readKeys(tree *critbit.Critbit[string], r io.Reader, name string, tsv bool) error {
+	scanner := bufio.NewScanner(r)
+	// A line can't be longer than an int, even if a key can
+	scanner.Buffer(make([]byte, 64*1024), min(critbit.MaxStringLength+1024*1024, math.MaxInt))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		key, value := line, ""
+		if tsv {
+			var found bool
+			key, value, found = strings.Cut(line, "\t")
+			if !found {
+				return errors.Errorf("%s:%d: No tab between the key and the value", name, lineNum)
+			}
+		} else if line == "" {
+			continue
+		}
+		if err := tree.Upsert(key, value); err != nil {
+			return errors.Wrapf(err, "%s:%d", name, lineNum)
+		}
+	}
+	return errors.Wrapf(scanner.Err(), "Reading %s", name)
+}
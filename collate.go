@@ -0,0 +1,74 @@
+package critbit
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// A Collation transforms a key into the sort key that the tree indexes.
+// Keys whose sort keys are identical collide, and keys are iterated over
+// in the order of their sort keys. The tree still keeps, and returns,
+// the key as it was originally inserted.
+//
+// The prefix methods, like GetHasPrefix, collate the prefix too. They
+// only work as expected if the collation of a prefix of a key is a
+// prefix of the collation of the key, which is true of the collations
+// provided by this package.
+type Collation func(key string) []byte
+
+// WithCollation makes the tree index the keys through a collation,
+// instead of by their raw bytes.
+func WithCollation(collation Collation) Option {
+	return func(cfg *config) {
+		cfg.collation = collation
+	}
+}
+
+// FoldASCII is a Collation which folds ASCII upper case letters to
+// lower case, so that "Alice" and "alice" collide.
+func FoldASCII(key string) []byte {
+	sortKey := []byte(key)
+	for i, ch := range sortKey {
+		if 'A' <= ch && ch <= 'Z' {
+			sortKey[i] = ch + 'a' - 'A'
+		}
+	}
+	return sortKey
+}
+
+// FoldCase is a Collation which applies Unicode case folding.
+func FoldCase(key string) []byte {
+	return cases.Fold().Bytes([]byte(key))
+}
+
+// NormalizeNFC is a Collation which converts a key to Unicode
+// Normalization Form C, so that canonically equivalent keys collide.
+func NormalizeNFC(key string) []byte {
+	return norm.NFC.AppendString(nil, key)
+}
+
+// NormalizeNFKC is a Collation which converts a key to Unicode
+// Normalization Form KC, so that compatibility equivalent keys collide.
+func NormalizeNFKC(key string) []byte {
+	return norm.NFKC.AppendString(nil, key)
+}
+
+// ChainCollations returns a Collation which applies each of the
+// collations in turn.
+func ChainCollations(collations ...Collation) Collation {
+	return func(key string) []byte {
+		sortKey := []byte(key)
+		for _, collation := range collations {
+			sortKey = collation(bytesToString(sortKey))
+		}
+		return sortKey
+	}
+}
+
+// collate returns the sort key which the tree indexes for a key.
+func (tree *Critbit[T]) collate(key string) string {
+	if tree.config.collation == nil {
+		return key
+	}
+	return string(tree.config.collation(key))
+}
@@ -0,0 +1,84 @@
+package critbit
+
+import (
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) testFoldASCII(c *C, tree *Critbit[int]) {
+	for i, key := range []string{"carol", "Alice", "Bob", "alfred"} {
+		ok, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+		c.Check(ok, Equals, true)
+	}
+	// Collides with "Alice"
+	ok, err := tree.Insert("alice", 4)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, false)
+
+	c.Check(tree.Keys(), DeepEquals, []string{"alfred", "Alice", "Bob", "carol"})
+	c.Check(tree.TotalStringSize(), Equals, 19)
+
+	value, has := tree.Get("ALICE")
+	c.Check(has, Equals, true)
+	c.Check(value, Equals, 1)
+	value, has = tree.GetBytes([]byte("bob"))
+	c.Check(has, Equals, true)
+	c.Check(value, Equals, 2)
+
+	kvt := tree.GetHasPrefix("ALI")
+	c.Assert(kvt, NotNil)
+	c.Check(kvt.Key, Equals, "Alice")
+	c.Check(tree.GetHasPrefix("Ala"), IsNil)
+
+	// The original key is kept when updating through another case
+	c.Check(tree.Upsert("BOB", 5), IsNil)
+	c.Check(tree.Update("CAROL", 6), Equals, true)
+	var values []int
+	for key, value := range tree.IterateItems() {
+		c.Check(key, Not(Equals), "BOB")
+		values = append(values, value)
+	}
+	c.Check(values, DeepEquals, []int{3, 1, 5, 6})
+
+	left, right := tree.Split()
+	c.Check(left.Keys(), DeepEquals, []string{"alfred", "Alice"})
+	c.Check(right.Keys(), DeepEquals, []string{"Bob", "carol"})
+	_, has = right.Get("CAROL")
+	c.Check(has, Equals, true)
+
+	c.Check(tree.Delete("ALFRED"), Equals, true)
+	c.Check(tree.Delete("alfred"), Equals, false)
+	c.Check(tree.Keys(), DeepEquals, []string{"Alice", "Bob", "carol"})
+	c.Check(tree.TotalStringSize(), Equals, 13)
+}
+
+func (s *MySuite) TestCollationFoldASCII(c *C) {
+	s.testFoldASCII(c, New[int](0, WithCollation(FoldASCII)))
+}
+
+func (s *MySuite) TestCollationFoldASCIIKeyArena(c *C) {
+	tree := New[int](0, WithCollation(FoldASCII), WithKeyArena(0))
+	s.testFoldASCII(c, tree)
+	checkKeysInArena(c, tree)
+}
+
+func (s *MySuite) TestCollationNormalize(c *C) {
+	tree := New[int](0, WithCollation(NormalizeNFC))
+	ok, err := tree.Insert("café", 1)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, true)
+	ok, err = tree.Insert("cafe\u0301", 2)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, false)
+	value, has := tree.Get("cafe\u0301")
+	c.Check(has, Equals, true)
+	c.Check(value, Equals, 1)
+
+	tree = New[int](0, WithCollation(ChainCollations(NormalizeNFKC, FoldCase)))
+	tree.Insert("Ｆｏｏ", 1) // full-width "Foo"
+	ok, err = tree.Insert("FOO", 2)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, false)
+	c.Check(tree.Keys(), DeepEquals, []string{"Ｆｏｏ"})
+}
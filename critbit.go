@@ -4,8 +4,7 @@
 package critbit
 
 const (
-	kNilNode = (1<<32 - 1)
-	kNilRef  = kNilNode
+	kNilRef = kNilNode
 
 	kChildNil     = 0x00 // 00000000
 	kChildIntNode = 0x01 // 00000001
@@ -18,54 +17,65 @@ const (
 	kDirectionLeft  = 0
 	kDirectionRight = 1
 
-	// Since we use a uint32 to keep track of internal nodes and
-	// external references, we can store up to 2^32 of each. One value,
-	// 0xffffffff is used as a "nil" value. If we have N external refs,
-	// we need N-1 interal nodes to differentiate them. So, the max number
-	// of external refs (strings) is (2^32)-1, and accordingly, the max
-	// number of internal nodes we would use would be (2^32)-2.
-	kMaxStrings = (1 << 32) - 1
-
-	// A uint16 value is used to store the offset within a string,
-	// so the maximum allowed string length is 65,536.
-	MaxStringLength = 65536
+	// An internal node whose bit is kEndOfKey doesn't test a bit; it
+	// separates the keys which end at its offset from the keys which
+	// continue past it. So a key and the same key followed by a NUL
+	// byte are told apart, as in djb's crit-bit trees. Whether a key
+	// has a byte at an offset matters more than any bit of that byte.
+	// Keys which end partway through a byte are separated by the other
+	// end-of-key bits; see endOfKeyBit.
+	kEndOfKey = 0
 
+	// A keyOffset value is used to store the offset within a string,
+	// so the maximum allowed string length depends on the build; see
+	// MaxStringLength.
 	kMaxStringLength = MaxStringLength
 )
 
 // A Critbit represents one Critbit tree.
 type Critbit[T any] struct {
-	totalStringSize int
+	config          config
+	totalStringSize int         // sum of the lengths of all keys
+	arena           *keyArena   // nil unless WithKeyArena was given
+	originalKeys    []string    // indexed by refNum, if there is a collation
+	scores          *scoreIndex // nil until a score is set
+	subtreeSizes    []int       // indexed by nodeNum; nil without WithSubtreeSizes
+	keyPadding      []uint8     // indexed by refNum; nil until a key ends partway through a byte
 
 	internalNodes []internalNode
 	externalRefs  []externalRef[T]
 
-	numInternalNodes int    // num used, not num allocated
-	numExternalRefs  int    // num used, not num allocated
-	rootItem         uint32 // root node, or if no nodes, root ref
-	firstDeletedNode uint32 // kNilNode if none are deleted
-	firstDeletedRef  uint32 // kNilRef if none are deleted
+	numInternalNodes int       // num used, not num allocated
+	numExternalRefs  int       // num used, not num allocated
+	rootItem         nodeIndex // root node, or if no nodes, root ref
+	firstDeletedNode nodeIndex // kNilNode if none are deleted
+	firstDeletedRef  nodeIndex // kNilRef if none are deleted
 }
 
 type internalNode struct {
-	offset uint16
+	offset keyOffset
 	bit    uint8
-	flags  uint8     // leftChildType=(nil|int|ext), rightChildType=(nil|int|ext)
-	child  [2]uint32 // if deleted, child[1] = nextDeleted
+	flags  uint8        // leftChildType=(nil|int|ext), rightChildType=(nil|int|ext)
+	child  [2]nodeIndex // if deleted, child[1] = nextDeleted
 }
 
 type externalRef[T any] struct {
-	key            string
+	key            string // in the arena, if the tree has one
 	value          T
-	nextDeletedRef uint32 // if deleted, uint32 pointing to nextDeleted
+	nextDeletedRef nodeIndex // if deleted, nodeIndex pointing to nextDeleted
 }
 
 // New allocates a new Critbit tree and returns a pointer to it.
 // The capacityStrings argument allows smart allocation of the internal arrays,
 // if you happen to know that a tree will contain a certain amount of
 // strings. This is for efficiency only; capacityStrings does not impose any
-// limit on the number of strings.
-func New[T any](capacityStrings int) *Critbit[T] {
+// limit on the number of strings. Options, like WithKeyArena and
+// WithCollation, change how the tree stores its keys.
+func New[T any](capacityStrings int, options ...Option) *Critbit[T] {
+	return newWithConfig[T](capacityStrings, newConfig(options))
+}
+
+func newWithConfig[T any](capacityStrings int, cfg config) *Critbit[T] {
 	// For every external ref (string), we need one branching (internal) node,
 	// except for the very first external ref (hence, the minus one).
 	var capacityInternalNodes int = 0
@@ -73,12 +83,28 @@ func New[T any](capacityStrings int) *Critbit[T] {
 		capacityInternalNodes = capacityStrings - 1
 	}
 
-	return &Critbit[T]{
+	tree := &Critbit[T]{
+		config:           cfg,
 		internalNodes:    make([]internalNode, 0, capacityInternalNodes),
 		externalRefs:     make([]externalRef[T], 0, capacityStrings),
 		firstDeletedNode: kNilNode,
 		firstDeletedRef:  kNilRef,
 	}
+	if cfg.subtreeSizes {
+		tree.subtreeSizes = make([]int, 0, capacityInternalNodes)
+	}
+	if cfg.keyArena {
+		tree.arena = newKeyArena(cfg.arenaGarbageRatio)
+	}
+	if cfg.collation != nil {
+		tree.originalKeys = make([]string, 0, capacityStrings)
+	}
+	return tree
+}
+
+// newLike allocates an empty tree with the same options as this tree.
+func (tree *Critbit[T]) newLike(capacityStrings int) *Critbit[T] {
+	return newWithConfig[T](capacityStrings, tree.config)
 }
 
 // Length returns the number of keys currently stored in the tree. More space for
@@ -87,3 +113,10 @@ func New[T any](capacityStrings int) *Critbit[T] {
 func (tree *Critbit[T]) Length() int {
 	return tree.numExternalRefs
 }
+
+// TotalStringSize returns the sum of the lengths of all keys currently
+// stored in the tree. If the tree has a collation, these are the lengths
+// of the keys as they were inserted, not of their sort keys.
+func (tree *Critbit[T]) TotalStringSize() int {
+	return tree.totalStringSize
+}
@@ -0,0 +1,50 @@
+package critbittest
+
+import (
+	"testing"
+
+	"github.com/gilramir/critbit"
+)
+
+// Returns operations which insert the keys, split them at every point,
+// and then delete them, for seeding the fuzzers
+func seedOps(keys ...string) []byte {
+	var ops []Op
+	for _, key := range keys {
+		ops = append(ops, Op{Kind: OpInsert, Key: key})
+	}
+	for n := range keys {
+		ops = append(ops, Op{Kind: OpSplitAt, N: n, KeepRight: n%2 == 1})
+	}
+	for _, key := range keys {
+		ops = append(ops, Op{Kind: OpUpsert, Key: key}, Op{Kind: OpDelete, Key: key})
+	}
+	return EncodeOps(ops)
+}
+
+// The seeds are the keys of the package's table-driven tests
+func addSeeds(f *testing.F) {
+	f.Add(seedOps("@@@", "AAA", "BBB", "ZZZ", "DDD", "CCC", "zzz"))
+	f.Add(seedOps("CCC", "@@@", "AAA"))
+	f.Add(seedOps("a", "b", "c", "d", "k", "l", "m", "naa"))
+	f.Add(seedOps("a", "b", "c", "d", "k", "l", "m", "naa", "nab", "nac", "nad", "nba", "o", "p"))
+	f.Add(seedOps("a", "a\x00"))
+	f.Add(seedOps("", "\x00", "\x00\x00", "\x00\x01"))
+	f.Add(seedOps("apple", "app", "apply", "ape", "app\x00"))
+}
+
+func FuzzCritbit(f *testing.F) {
+	addSeeds(f)
+	harness := ForCritbit(intValue)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		harness.Check(t, DecodeOps(data))
+	})
+}
+
+func FuzzCritbitKeyArena(f *testing.F) {
+	addSeeds(f)
+	harness := ForCritbit(intValue, critbit.WithKeyArena(0.25))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		harness.Check(t, DecodeOps(data))
+	})
+}
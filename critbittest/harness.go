@@ -0,0 +1,216 @@
+// Package critbittest checks a critbit tree, or a wrapper around one,
+// against a simple model: a map and a sorted slice of keys. A Harness
+// applies a sequence of operations to both, and after each one, compares
+// their contents and checks the tree's structural invariants.
+package critbittest
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/gilramir/critbit"
+	"github.com/pkg/errors"
+)
+
+// Tree is the part of a critbit tree's API which a Harness uses.
+// *critbit.Critbit[T] implements it, and so can wrappers around it.
+type Tree[T any] interface {
+	Insert(key string, value T) (bool, error)
+	Upsert(key string, value T) error
+	Update(key string, value T) bool
+	Delete(key string) bool
+	Get(key string) (T, bool)
+	Keys() []string
+	Length() int
+}
+
+// A Harness applies operations to trees made by New, and to a model of
+// them, and compares the results. The model compares keys byte by byte,
+// so the trees must not have a collation.
+type Harness[T any] struct {
+	// New returns an empty tree
+	New func() Tree[T]
+
+	// Value returns the value for the operation at a step
+	Value func(step int) T
+
+	// Validate, if not nil, checks a tree's structural invariants
+	Validate func(tree Tree[T]) error
+
+	// SplitAt, if not nil, splits a tree in two, with n keys in the left
+	// tree. If it is nil, OpSplitAt operations are skipped.
+	SplitAt func(tree Tree[T], n int) (Tree[T], Tree[T])
+}
+
+// ForCritbit returns a Harness for critbit trees made with the options,
+// which validates the trees and splits them with SplitAt.
+func ForCritbit[T any](value func(step int) T, options ...critbit.Option) *Harness[T] {
+	return &Harness[T]{
+		New: func() Tree[T] {
+			return critbit.New[T](0, options...)
+		},
+		Value: value,
+		Validate: func(tree Tree[T]) error {
+			return tree.(*critbit.Critbit[T]).Validate()
+		},
+		SplitAt: func(tree Tree[T], n int) (Tree[T], Tree[T]) {
+			return tree.(*critbit.Critbit[T]).SplitAt(n)
+		},
+	}
+}
+
+// A model is what a tree should hold
+type model[T any] struct {
+	values map[string]T
+	keys   []string // sorted
+}
+
+func newModel[T any]() *model[T] {
+	return &model[T]{values: make(map[string]T)}
+}
+
+func (m *model[T]) has(key string) bool {
+	_, found := m.values[key]
+	return found
+}
+
+func (m *model[T]) set(key string, value T) {
+	if !m.has(key) {
+		i, _ := slices.BinarySearch(m.keys, key)
+		m.keys = slices.Insert(m.keys, i, key)
+	}
+	m.values[key] = value
+}
+
+func (m *model[T]) delete(key string) {
+	if i, found := slices.BinarySearch(m.keys, key); found {
+		m.keys = slices.Delete(m.keys, i, i+1)
+		delete(m.values, key)
+	}
+}
+
+func (m *model[T]) splitAt(n int) (*model[T], *model[T]) {
+	left, right := newModel[T](), newModel[T]()
+	for i, key := range m.keys {
+		if i < n {
+			left.set(key, m.values[key])
+		} else {
+			right.set(key, m.values[key])
+		}
+	}
+	return left, right
+}
+
+// Run applies the operations to a new tree and to the model, and returns
+// an error describing the first difference between them, or the first
+// invariant which the tree breaks.
+func (h *Harness[T]) Run(ops []Op) error {
+	tree := h.New()
+	m := newModel[T]()
+	for step, op := range ops {
+		var err error
+		tree, m, err = h.apply(tree, m, step, op)
+		if err == nil {
+			err = h.compare(tree, m, op.Key)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Step %d, %s", step, op)
+		}
+	}
+	return nil
+}
+
+// Check runs the operations, and fails the test if Run returns an error.
+func (h *Harness[T]) Check(t testing.TB, ops []Op) {
+	t.Helper()
+	if err := h.Run(ops); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (h *Harness[T]) apply(tree Tree[T], m *model[T], step int, op Op) (Tree[T], *model[T], error) {
+	present := m.has(op.Key)
+	switch op.Kind {
+	case OpInsert:
+		value := h.Value(step)
+		ok, err := tree.Insert(op.Key, value)
+		if err != nil {
+			return tree, m, err
+		}
+		if ok == present {
+			return tree, m, errors.Errorf("Insert returned %v, but the key was present=%v", ok, present)
+		}
+		if ok {
+			m.set(op.Key, value)
+		}
+	case OpUpsert:
+		value := h.Value(step)
+		if err := tree.Upsert(op.Key, value); err != nil {
+			return tree, m, err
+		}
+		m.set(op.Key, value)
+	case OpUpdate:
+		value := h.Value(step)
+		if ok := tree.Update(op.Key, value); ok != present {
+			return tree, m, errors.Errorf("Update returned %v, but the key was present=%v", ok, present)
+		}
+		if present {
+			m.set(op.Key, value)
+		}
+	case OpDelete:
+		if ok := tree.Delete(op.Key); ok != present {
+			return tree, m, errors.Errorf("Delete returned %v, but the key was present=%v", ok, present)
+		}
+		m.delete(op.Key)
+	case OpSplitAt:
+		if h.SplitAt == nil {
+			return tree, m, nil
+		}
+		n := op.N % (len(m.keys) + 1)
+		leftTree, rightTree := h.SplitAt(tree, n)
+		leftModel, rightModel := m.splitAt(n)
+		if err := h.compare(leftTree, leftModel, ""); err != nil {
+			return tree, m, errors.Wrap(err, "Left tree")
+		}
+		if err := h.compare(rightTree, rightModel, ""); err != nil {
+			return tree, m, errors.Wrap(err, "Right tree")
+		}
+		if op.KeepRight {
+			return rightTree, rightModel, nil
+		}
+		return leftTree, leftModel, nil
+	default:
+		return tree, m, errors.Errorf("Unknown operation %s", op.Kind)
+	}
+	return tree, m, nil
+}
+
+// Compares the tree with the model. The key, which the last operation
+// used, is looked up too, in case the tree has it but the model doesn't.
+func (h *Harness[T]) compare(tree Tree[T], m *model[T], key string) error {
+	if tree.Length() != len(m.keys) {
+		return errors.Errorf("Length() = %d, expected %d", tree.Length(), len(m.keys))
+	}
+	if keys := tree.Keys(); !slices.Equal(keys, m.keys) && len(keys)+len(m.keys) > 0 {
+		return errors.Errorf("Keys() = %q, expected %q", keys, m.keys)
+	}
+	for _, k := range m.keys {
+		value, found := tree.Get(k)
+		if !found {
+			return errors.Errorf("Get(%q) didn't find the key", k)
+		}
+		if !reflect.DeepEqual(value, m.values[k]) {
+			return errors.Errorf("Get(%q) = %v, expected %v", k, value, m.values[k])
+		}
+	}
+	if _, found := tree.Get(key); found != m.has(key) {
+		return errors.Errorf("Get(%q) found the key=%v, expected %v", key, found, m.has(key))
+	}
+	if h.Validate != nil {
+		if err := h.Validate(tree); err != nil {
+			return errors.Wrap(err, "Invalid tree")
+		}
+	}
+	return nil
+}
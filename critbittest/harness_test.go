@@ -0,0 +1,132 @@
+package critbittest
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/gilramir/critbit"
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func intValue(step int) int {
+	return step
+}
+
+// Keys which share prefixes, and differ in length by NULs
+func testKeys() []string {
+	var keys []string
+	for _, base := range []string{"", "a", "ab", "abc", "b", "\x00", "\xff"} {
+		keys = append(keys, base, base+"\x00", base+"a", base+"\x00\x00")
+	}
+	for i := 0; i < 50; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i*i))
+	}
+	return keys
+}
+
+func (s *MySuite) TestRandomOps(c *C) {
+	rng := rand.New(rand.NewSource(1))
+	keys := testKeys()
+	harnesss := map[string]*Harness[int]{
+		"default":  ForCritbit(intValue),
+		"arena":    ForCritbit(intValue, critbit.WithKeyArena(0.1)),
+		"no split": {New: ForCritbit(intValue).New, Value: intValue},
+	}
+	for name, harness := range harnesss {
+		for run := 0; run < 20; run++ {
+			ops := RandomOps(rng, 300, keys)
+			c.Check(harness.Run(ops), IsNil, Commentf("%s, run %d", name, run))
+		}
+	}
+}
+
+func (s *MySuite) TestEncodeOps(c *C) {
+	ops := []Op{
+		{Kind: OpInsert, Key: "a\x00"},
+		{Kind: OpUpsert, Key: ""},
+		{Kind: OpUpdate, Key: "1234567"},
+		{Kind: OpDelete, Key: "a"},
+		{Kind: OpSplitAt, N: 3},
+		{Kind: OpSplitAt, N: 200, KeepRight: true},
+	}
+	c.Check(DecodeOps(EncodeOps(ops)), DeepEquals, ops)
+
+	// Long keys are cut short, and trailing bytes are ignored
+	long := []Op{{Kind: OpInsert, Key: "12345678"}}
+	c.Check(DecodeOps(EncodeOps(long)), DeepEquals, []Op{{Kind: OpInsert, Key: "1234567"}})
+	c.Check(DecodeOps(append(EncodeOps(ops), 1)), DeepEquals, ops)
+	c.Check(DecodeOps(nil), IsNil)
+
+	c.Check(ops[0].String(), Equals, `Insert("a\x00")`)
+	c.Check(ops[5].String(), Equals, "SplitAt(200), keeping the right tree")
+	c.Check(OpKind(9).String(), Equals, "OpKind(9)")
+}
+
+// A forgetfulTree doesn't delete keys which start with "x"
+type forgetfulTree struct {
+	*critbit.Critbit[int]
+}
+
+func (tree forgetfulTree) Delete(key string) bool {
+	if len(key) > 0 && key[0] == 'x' {
+		_, found := tree.Get(key)
+		return found
+	}
+	return tree.Critbit.Delete(key)
+}
+
+// A wrongSplitter swaps the halves of a split
+func wrongSplitter(tree Tree[int], n int) (Tree[int], Tree[int]) {
+	left, right := tree.(*critbit.Critbit[int]).SplitAt(n)
+	return right, left
+}
+
+func (s *MySuite) TestCheckerFindsBugs(c *C) {
+	forgetful := &Harness[int]{
+		New: func() Tree[int] {
+			return forgetfulTree{critbit.New[int](0)}
+		},
+		Value: intValue,
+	}
+	ops := []Op{
+		{Kind: OpInsert, Key: "a"},
+		{Kind: OpInsert, Key: "xyz"},
+		{Kind: OpDelete, Key: "a"},
+		{Kind: OpDelete, Key: "xyz"},
+	}
+	c.Check(forgetful.Run(ops), ErrorMatches, `Step 3, Delete\("xyz"\): Length\(\) = 1, expected 0`)
+
+	splitter := ForCritbit(intValue)
+	splitter.SplitAt = wrongSplitter
+	ops = []Op{
+		{Kind: OpInsert, Key: "a"},
+		{Kind: OpInsert, Key: "b"},
+		{Kind: OpSplitAt, N: 1},
+	}
+	c.Check(splitter.Run(ops), ErrorMatches,
+		`Step 2, SplitAt\(1\), keeping the left tree: Left tree: Keys\(\) = \["b"\], expected \["a"\]`)
+
+	// Values are compared too
+	ops = []Op{
+		{Kind: OpInsert, Key: "a"},
+		{Kind: OpUpdate, Key: "a"},
+	}
+	valueDropping := &Harness[int]{
+		New: func() Tree[int] {
+			return valueDroppingTree{critbit.New[int](0)}
+		},
+		Value: intValue,
+	}
+	c.Check(valueDropping.Run(ops), ErrorMatches, `Step 1, Update\("a"\): Get\("a"\) = 0, expected 1`)
+}
+
+// A valueDroppingTree's Update doesn't change the value
+type valueDroppingTree struct {
+	*critbit.Critbit[int]
+}
+
+func (tree valueDroppingTree) Update(key string, value int) bool {
+	_, found := tree.Get(key)
+	return found
+}
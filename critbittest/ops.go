@@ -0,0 +1,124 @@
+package critbittest
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// An OpKind is a kind of operation which a Harness applies.
+type OpKind byte
+
+const (
+	OpInsert OpKind = iota
+	OpUpsert
+	OpUpdate
+	OpDelete
+	OpSplitAt
+	numOpKinds
+)
+
+var opNames = [numOpKinds]string{"Insert", "Upsert", "Update", "Delete", "SplitAt"}
+
+func (kind OpKind) String() string {
+	if kind < numOpKinds {
+		return opNames[kind]
+	}
+	return fmt.Sprintf("OpKind(%d)", byte(kind))
+}
+
+// An Op is one operation which a Harness applies to a tree and to its
+// model. The value of an Insert, Upsert or Update comes from the
+// Harness's Value function.
+type Op struct {
+	Kind OpKind
+
+	// The key, for every kind but OpSplitAt
+	Key string
+
+	// For OpSplitAt, N is the number of keys for the left tree, modulo
+	// the number of keys plus one, and the harness carries on with the
+	// right tree if KeepRight is set, or the left tree if not. Both trees
+	// are checked.
+	N         int
+	KeepRight bool
+}
+
+func (op Op) String() string {
+	if op.Kind == OpSplitAt {
+		side := "left"
+		if op.KeepRight {
+			side = "right"
+		}
+		return fmt.Sprintf("SplitAt(%d), keeping the %s tree", op.N, side)
+	}
+	return fmt.Sprintf("%s(%q)", op.Kind, op.Key)
+}
+
+// RandomOps returns n random operations on the given keys. Most of them
+// are inserts, so that the trees grow, and one in 50 is a split.
+func RandomOps(rng *rand.Rand, n int, keys []string) []Op {
+	ops := make([]Op, n)
+	for i := range ops {
+		op := &ops[i]
+		switch r := rng.Intn(50); {
+		case r == 0:
+			op.Kind = OpSplitAt
+			op.N = rng.Intn(n + 1)
+			op.KeepRight = rng.Intn(2) == 1
+			continue
+		case r < 20:
+			op.Kind = OpInsert
+		case r < 30:
+			op.Kind = OpUpsert
+		case r < 38:
+			op.Kind = OpUpdate
+		default:
+			op.Kind = OpDelete
+		}
+		op.Key = keys[rng.Intn(len(keys))]
+	}
+	return ops
+}
+
+// DecodeOps turns arbitrary bytes, such as a fuzzer's input, into
+// operations. Each operation is a byte which selects the kind, followed
+// by a length byte and up to 7 bytes of key, or for a split, a byte for
+// N. The keys are short, so that they often collide and share prefixes.
+// EncodeOps is the inverse, for seeding a fuzzer.
+func DecodeOps(data []byte) []Op {
+	var ops []Op
+	for len(data) >= 2 {
+		op := Op{Kind: OpKind(data[0] % byte(numOpKinds))}
+		if op.Kind == OpSplitAt {
+			op.KeepRight = data[0]/byte(numOpKinds)%2 == 1
+			op.N = int(data[1])
+			data = data[2:]
+		} else {
+			n := min(int(data[1]%8), len(data)-2)
+			op.Key = string(data[2 : 2+n])
+			data = data[2+n:]
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// EncodeOps returns the bytes which DecodeOps turns into the operations.
+// Keys longer than 7 bytes, and N greater than 255, are cut short.
+func EncodeOps(ops []Op) []byte {
+	var data []byte
+	for _, op := range ops {
+		if op.Kind == OpSplitAt {
+			kind := byte(OpSplitAt)
+			if op.KeepRight {
+				kind += byte(numOpKinds)
+			}
+			data = append(data, kind, byte(min(op.N, 255)))
+			continue
+		}
+		key := op.Key[:min(len(op.Key), 7)]
+		data = append(data, byte(op.Kind), byte(len(key)))
+		data = append(data, key...)
+	}
+	return data
+}
@@ -7,6 +7,22 @@ import (
 // Delete removes the key from the tree. The boolean return value
 // indicates if the key was in the tree.
 func (tree *Critbit[T]) Delete(key string) bool {
+	key = tree.collate(key)
+	return tree.delete(key, bitLen(key))
+}
+
+// The key is the sort key, and keyBits is its length in bits.
+func (tree *Critbit[T]) delete(key string, keyBits int64) bool {
+	if !tree.deleteRef(key, keyBits) {
+		return false
+	}
+	if tree.hasAugments() {
+		tree.refreshAugments(key, keyBits)
+	}
+	return true
+}
+
+func (tree *Critbit[T]) deleteRef(key string, keyBits int64) bool {
 	// Is the tree empty? Do nothing
 	if tree.numExternalRefs == 0 {
 		return false
@@ -14,10 +30,10 @@ func (tree *Critbit[T]) Delete(key string) bool {
 
 	// Find the best external reference
 	bestRefNum, grandparentNodeNum, grandparentDirection, parentNodeNum, parentDirection,
-		parentIsRoot := tree.findBestExternalReferenceWithAncestry(key)
+		parentIsRoot := tree.findBestExternalReferenceWithAncestry(key, keyBits)
 
 	// find critical bit
-	identical, _, _, _ := tree.findCriticalBit(bestRefNum, key)
+	identical, _, _, _ := tree.findCriticalBit(bestRefNum, key, keyBits)
 
 	// Is it NOT in the tree?
 	if !identical {
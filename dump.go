@@ -1,58 +1,48 @@
 package critbit
 
 import (
-	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
 // Dump prints the structure of the entire tree to stdout.
 func (tree *Critbit[T]) Dump() {
-	fmt.Printf("Tree length=%d\n", tree.numExternalRefs)
-	if tree.numExternalRefs == 0 {
-		return
-	} else if tree.numExternalRefs == 1 {
-		tree.dumpExternalRef("Root:", tree.rootItem, "")
-		return
-	}
-	tree.dumpInternalNode("Root:", tree.rootItem, "")
-}
-
-func (tree *Critbit[T]) dumpExternalRef(title string, refNum uint32, indent string) {
-	// One ref, and it's the root and leaf (no internal nodes)
-	fmt.Printf("%s%s refNum=%d (EXT) key=%s\n", indent,
-		title, refNum, tree.externalRefs[refNum].key)
+	// Ignore errors
+	_ = tree.DumpTo(os.Stdout)
 }
 
-func (tree *Critbit[T]) dumpInternalNode(title string, nodeNum uint32, indent string) {
-	node := &tree.internalNodes[nodeNum]
-	fmt.Printf("%s%s nodeNum=%d (INT) off=%d bit=0x%01x\n", indent,
-		title, nodeNum, node.offset, node.bit)
-
-	indent += "  "
-	switch node.getChildType(0) {
-	case kChildNil:
-		fmt.Printf("%sLeft  type is nil, value=%d\n", indent, node.child[0])
-	case kChildIntNode:
-		tree.dumpInternalNode("Left ", node.child[0], indent)
-	case kChildExtRef:
-		tree.dumpExternalRef("Left ", node.child[0], indent)
-	default:
-		fmt.Printf("%sUnexpected left childType=%d value=%d\n",
-			indent, node.getChildType(0), node.child[0])
-	}
-	switch node.getChildType(1) {
-	case kChildNil:
-		fmt.Printf("%sRight type is nil, value=%d\n", indent, node.child[1])
-	case kChildIntNode:
-		tree.dumpInternalNode("Right", node.child[1], indent)
-	case kChildExtRef:
-		tree.dumpExternalRef("Right", node.child[1], indent)
-	default:
-		fmt.Printf("%sUnexpected right childType=%d value=%d\n",
-			indent, node.getChildType(1), node.child[1])
-	}
+// DumpTo writes the structure of the entire tree to w, as indented text.
+// Keys are quoted, so that binary keys are readable. If the tree has a
+// collation, the keys are shown as they were inserted, as the other
+// exporters show them, rather than as their sort keys.
+func (tree *Critbit[T]) DumpTo(w io.Writer) error {
+	out := &errWriter{w: w}
+	out.printf("Tree length=%d\n", tree.numExternalRefs)
+	tree.walkStructure(0, func(item *structureItem) {
+		indent := strings.Repeat("  ", item.depth)
+		title := "Root:"
+		if item.parent != nil {
+			title = [2]string{"Left ", "Right"}[item.direction]
+		}
+		switch item.itemType {
+		case kChildNil:
+			out.printf("%s%s type is nil, value=%d\n", indent, title, item.itemID)
+		case kChildIntNode:
+			node := &tree.internalNodes[item.itemID]
+			out.printf("%s%s nodeNum=%d (INT) off=%d bit=%s\n", indent,
+				title, item.itemID, node.offset, bitLabel(node.bit))
+		case kChildExtRef:
+			out.printf("%s%s refNum=%d (EXT) key=%q\n", indent,
+				title, item.itemID, tree.refOriginalKey(item.itemID))
+		default:
+			out.printf("%sUnexpected %s childType=%d value=%d\n",
+				indent, strings.TrimSpace(title), item.itemType, item.itemID)
+		}
+	}, nil)
+	return out.err
 }
 
 // SaveDot save the tree structure to a graphviz/dot file with the
@@ -63,114 +53,75 @@ func (tree *Critbit[T]) SaveDot(filename string) error {
 	if err != nil {
 		return errors.Wrapf(err, "Opening %s for writing", filename)
 	}
-
-	// Ignore errors
-	defer outputFile.Close()
-
-	_, err = outputFile.WriteString("digraph xbtrie_critbit {\n")
-	if err != nil {
-		return err
-	}
-
-	if tree.numExternalRefs == 0 {
-		// no-op
-	} else if tree.numExternalRefs == 1 {
-		tree.saveDotExternalRef(outputFile, tree.rootItem)
-	} else {
-		tree.saveDotInternalNode(outputFile, tree.rootItem)
+	err = tree.WriteDot(outputFile, ExportOptions{})
+	if closeErr := outputFile.Close(); err == nil && closeErr != nil {
+		err = errors.Wrapf(closeErr, "Closing %s", filename)
 	}
-
-	_, err = outputFile.WriteString("}\n")
-	if err != nil {
-		return err
-	}
-	return nil
+	return err
 }
 
-func (tree *Critbit[T]) saveDotExternalRef(outputFile *os.File, refNum uint32) error {
-	var err error
-	name := fmt.Sprintf("ref_%d", refNum)
+// WriteDot writes the tree structure to w in graphviz/dot format.
+func (tree *Critbit[T]) WriteDot(w io.Writer, options ExportOptions) error {
+	out := &errWriter{w: w}
+	highlighted := tree.highlightedItems(options.HighlightKeys)
 
-	_, err = outputFile.WriteString(
-		fmt.Sprintf("\t%s [label=\"%s\\nrefNum=%d\" shape=\"box\"]\n",
-			name, tree.externalRefs[refNum].key, refNum))
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (tree *Critbit[T]) saveDotInternalNode(outputFile *os.File, nodeNum uint32) error {
-	var err error
-	name := fmt.Sprintf("node_%d", nodeNum)
-	node := &tree.internalNodes[nodeNum]
-
-	// Internal node
-	_, err = outputFile.WriteString(
-		fmt.Sprintf("\t%s [label=\"off=0x%02x\\nbit=%d\\nnodeNum=%d\"]\n",
-			name, node.offset, node.bit, nodeNum))
-	if err != nil {
-		return err
-	}
-
-	var leftName string
-	var rightName string
-
-	switch node.getChildType(0) {
-	case kChildNil:
-		// no-op
-	case kChildIntNode:
-		leftName = fmt.Sprintf("node_%d", node.child[0])
-		err = tree.saveDotInternalNode(outputFile, node.child[0])
-	case kChildExtRef:
-		leftName = fmt.Sprintf("ref_%d", node.child[0])
-		err = tree.saveDotExternalRef(outputFile, node.child[0])
-	default:
-		return errors.Errorf("Unexpected left childType=%d value=%d",
-			node.getChildType(0), node.child[0])
-	}
-	if err != nil {
-		return err
-	}
-	switch node.getChildType(1) {
-	case kChildNil:
-		// no-op
-	case kChildIntNode:
-		rightName = fmt.Sprintf("node_%d", node.child[1])
-		err = tree.saveDotInternalNode(outputFile, node.child[1])
-	case kChildExtRef:
-		rightName = fmt.Sprintf("ref_%d", node.child[1])
-		err = tree.saveDotExternalRef(outputFile, node.child[1])
-	default:
-		return errors.Errorf("Unexpected right childType=%d value=%d",
-			node.getChildType(1), node.child[1])
-	}
-	if err != nil {
-		return err
+	// Returns the attributes which highlight an item or an edge, if needed
+	style := func(name string) string {
+		if highlighted[name] {
+			return " color=\"red\" penwidth=2"
+		}
+		return ""
 	}
 
-	if leftName == "" {
-		_, err = outputFile.WriteString(fmt.Sprintf("\t%s -> %s_lnil\n", name, name))
-		if err != nil {
-			return err
+	out.printf("digraph xbtrie_critbit {\n")
+	tree.walkStructure(options.MaxDepth, func(item *structureItem) {
+		name := item.name()
+		label := dotQuote(strings.Join(tree.itemLabel(item, options.ShowValues), "\n"))
+		switch {
+		case item.truncated:
+			out.printf("\t%s [label=%s shape=\"folder\"%s]\n", name, label, style(name))
+		case item.itemType == kChildIntNode:
+			out.printf("\t%s [label=%s%s]\n", name, label, style(name))
+		case item.itemType == kChildExtRef:
+			out.printf("\t%s [label=%s shape=\"box\"%s]\n", name, label, style(name))
+		case item.itemType == kChildNil:
+			// Missing children are drawn by dot as plain ellipses
+		default:
+			out.fail(errors.Errorf("Node %d has unexpected child type %d in direction %d",
+				item.parent.itemID, item.itemType, item.direction))
+			return
 		}
-	} else {
-		_, err = outputFile.WriteString(fmt.Sprintf("\t%s -> %s\n", name, leftName))
-		if err != nil {
-			return err
+		if item.parent == nil {
+			return
 		}
-	}
-	if rightName == "" {
-		_, err = outputFile.WriteString(fmt.Sprintf("\t%s -> %s_rnil\n", name, name))
-		if err != nil {
-			return err
+		// An edge is highlighted if both of its ends are
+		parentName := item.parent.name()
+		edgeStyle := ""
+		if highlighted[parentName] {
+			edgeStyle = style(name)
 		}
-	} else {
-		_, err = outputFile.WriteString(fmt.Sprintf("\t%s -> %s\n", name, rightName))
-		if err != nil {
-			return err
+		out.printf("\t%s -> %s [label=\"%d\"%s]\n", parentName, name, item.direction, edgeStyle)
+	}, nil)
+	out.printf("}\n")
+	return out.err
+}
+
+// Quotes a label for dot. The label is shown as it is, except that
+// newlines start new lines in the label.
+func dotQuote(label string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range label {
+		switch r {
+		case '"', '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		default:
+			sb.WriteRune(r)
 		}
 	}
-	return nil
+	sb.WriteByte('"')
+	return sb.String()
 }
@@ -0,0 +1,106 @@
+package critbit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func newDumpTestTree(c *C) *Critbit[int] {
+	tree := New[int](0)
+	for i, key := range []string{"a", `say "hi"`, `back\slash`, "a\x00"} {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+	}
+	return tree
+}
+
+func (s *MySuite) TestDumpTo(c *C) {
+	tree := newDumpTestTree(c)
+	var buf bytes.Buffer
+	c.Assert(tree.DumpTo(&buf), IsNil)
+	c.Check(buf.String(), Equals, `Tree length=4
+Root: nodeNum=0 (INT) off=0 bit=0x10
+  Left  nodeNum=1 (INT) off=0 bit=0x02
+    Left  nodeNum=2 (INT) off=1 bit=end
+      Left  refNum=0 (EXT) key="a"
+      Right refNum=3 (EXT) key="a\x00"
+    Right refNum=2 (EXT) key="back\\slash"
+  Right refNum=1 (EXT) key="say \"hi\""
+`)
+}
+
+func (s *MySuite) TestDumpToCollation(c *C) {
+	tree := New[int](0, WithCollation(FoldASCII))
+	tree.Insert("Bob", 1)
+	tree.Insert("alice", 2)
+	var buf bytes.Buffer
+	c.Assert(tree.DumpTo(&buf), IsNil)
+	c.Check(buf.String(), Equals, `Tree length=2
+Root: nodeNum=0 (INT) off=0 bit=0x02
+  Left  refNum=1 (EXT) key="alice"
+  Right refNum=0 (EXT) key="Bob"
+`)
+}
+
+func (s *MySuite) TestWriteDot(c *C) {
+	tree := newDumpTestTree(c)
+	var buf bytes.Buffer
+	c.Assert(tree.WriteDot(&buf, DotOptions{}), IsNil)
+	dot := buf.String()
+	c.Check(strings.HasPrefix(dot, "digraph xbtrie_critbit {\n"), Equals, true)
+	c.Check(strings.HasSuffix(dot, "}\n"), Equals, true)
+	// Quotes and backslashes in keys are escaped twice: once to show
+	// the key as a Go string, and once for dot
+	c.Check(dot, Matches, `(?s).*\tref_1 \[label="\\"say \\\\\\"hi\\\\\\"\\"\\nrefNum=1" shape="box"\].*`)
+	c.Check(dot, Matches, `(?s).*\tref_2 \[label="\\"back\\\\\\\\slash\\"\\nrefNum=2" shape="box"\].*`)
+	c.Check(dot, Matches, `(?s).*\tnode_2 -> ref_3 \[label="1"\].*`)
+	c.Check(strings.Contains(dot, "value="), Equals, false)
+	c.Check(strings.Contains(dot, "red"), Equals, false)
+}
+
+func (s *MySuite) TestWriteDotOptions(c *C) {
+	tree := newDumpTestTree(c)
+	var buf bytes.Buffer
+	err := tree.WriteDot(&buf, DotOptions{
+		ShowValues:    true,
+		HighlightKeys: []string{"a\x00", "say"},
+		MaxDepth:      1,
+	})
+	c.Assert(err, IsNil)
+	dot := buf.String()
+	c.Check(dot, Matches, `(?s).*\tref_2 \[label="\\"back\\\\\\\\slash\\"\\nrefNum=2\\nvalue=2" shape="box"\].*`)
+	// A lookup of "say" ends at the ref with "say \"hi\""
+	c.Check(dot, Matches, `(?s).*\tref_1 \[label=.*value=1" shape="box" color="red" penwidth=2\].*`)
+	c.Check(dot, Matches, `(?s).*\tnode_0 -> node_1 \[label="0" color="red" penwidth=2\].*`)
+	c.Check(dot, Matches, `(?s).*\tnode_1 -> ref_2 \[label="1"\].*`)
+	c.Check(dot, Matches, `(?s).*\tnode_1 -> node_2 \[label="0" color="red" penwidth=2\].*`)
+
+	// Below the maximum depth, subtrees are summarized
+	c.Check(dot, Matches, `(?s).*\tnode_2 \[label="2 keys" shape="folder" color="red" penwidth=2\].*`)
+	c.Check(strings.Contains(dot, "ref_3"), Equals, false)
+}
+
+// A failingWriter fails after writing some bytes
+type failingWriter struct {
+	remaining int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.remaining {
+		w.remaining = 0
+		return 0, errors.New("disk full")
+	}
+	w.remaining -= len(p)
+	return len(p), nil
+}
+
+func (s *MySuite) TestWriteErrors(c *C) {
+	tree := newDumpTestTree(c)
+	c.Check(tree.WriteDot(&failingWriter{remaining: 100}, DotOptions{}), ErrorMatches, "disk full")
+	c.Check(tree.DumpTo(&failingWriter{remaining: 100}), ErrorMatches, "disk full")
+	c.Check(tree.SaveDot("/nonexistent/directory/tree.dot"), ErrorMatches, "Opening .*")
+}
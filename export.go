@@ -0,0 +1,372 @@
+package critbit
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// ExportOptions changes what WriteDot, WriteMermaid, WriteJSON and
+// WriteASCII write.
+type ExportOptions struct {
+	// ShowValues adds each key's value to its ref.
+	ShowValues bool
+
+	// HighlightKeys highlights the path which a lookup of each of these
+	// keys takes from the root.
+	HighlightKeys []string
+
+	// MaxDepth, if not 0, replaces the internal nodes deeper than
+	// MaxDepth with a placeholder saying how many keys they hold.
+	MaxDepth int
+}
+
+// DotOptions are the options for WriteDot.
+type DotOptions = ExportOptions
+
+// A structureItem is an internal node, an external ref, or a missing
+// child, as visited by walkStructure.
+type structureItem struct {
+	itemType  byte // a kChild* constant, or an unexpected value in a corrupt tree
+	itemID    nodeIndex
+	parent    *structureItem // nil for the root
+	direction byte           // the direction from the parent to the item
+	depth     int            // the number of internal nodes above the item
+
+	// If the item is an internal node beyond the maximum depth, its
+	// children are not visited, and numKeys is the number of keys under it
+	truncated bool
+	numKeys   int
+}
+
+// walkStructure visits every item in the tree, depth-first, left before
+// right. It calls enter for each item, and for internal nodes, calls leave
+// after visiting the node's children. The exporters all share this
+// traversal, so they agree on what they show.
+func (tree *Critbit[T]) walkStructure(maxDepth int, enter func(item *structureItem),
+	leave func(item *structureItem)) {
+	if tree.numExternalRefs == 0 {
+		return
+	}
+	root := &structureItem{itemType: tree.rootItemType(), itemID: tree.rootItem}
+	tree.walkStructureItem(root, maxDepth, enter, leave)
+}
+
+func (tree *Critbit[T]) walkStructureItem(item *structureItem, maxDepth int,
+	enter func(item *structureItem), leave func(item *structureItem)) {
+	if item.itemType == kChildIntNode && maxDepth > 0 && item.depth > maxDepth {
+		item.truncated = true
+		tree.walkRefs(kChildIntNode, item.itemID, func(nodeIndex) bool {
+			item.numKeys++
+			return true
+		})
+	}
+	enter(item)
+	if item.itemType != kChildIntNode || item.truncated {
+		return
+	}
+	node := &tree.internalNodes[item.itemID]
+	for direction := byte(0); direction < 2; direction++ {
+		child := &structureItem{
+			itemType:  node.getChildType(direction),
+			itemID:    node.child[direction],
+			parent:    item,
+			direction: direction,
+			depth:     item.depth + 1,
+		}
+		tree.walkStructureItem(child, maxDepth, enter, leave)
+	}
+	if leave != nil {
+		leave(item)
+	}
+}
+
+// Returns the names of the items on the paths which lookups of the keys
+// take, as returned by itemName.
+func (tree *Critbit[T]) highlightedItems(keys []string) map[string]bool {
+	highlighted := make(map[string]bool)
+	for _, key := range keys {
+		key = tree.collate(key)
+		itemType := tree.rootItemType()
+		itemID := tree.rootItem
+		for itemType == kChildIntNode {
+			highlighted[itemName(itemType, itemID)] = true
+			node := &tree.internalNodes[itemID]
+			direction := node.direction(key, bitLen(key))
+			itemType = node.getChildType(direction)
+			itemID = node.child[direction]
+		}
+		if itemType == kChildExtRef {
+			highlighted[itemName(itemType, itemID)] = true
+		}
+	}
+	return highlighted
+}
+
+// Returns a name for an item which is unique within the tree, and is a
+// valid identifier in dot and Mermaid. A missing child is named after its
+// parent.
+func (item *structureItem) name() string {
+	if item.itemType == kChildNil || item.itemType&^kChildBitmask != 0 {
+		return fmt.Sprintf("%s_%s", item.parent.name(), [2]string{"lnil", "rnil"}[item.direction])
+	}
+	return itemName(item.itemType, item.itemID)
+}
+
+func itemName(itemType byte, itemID nodeIndex) string {
+	if itemType == kChildExtRef {
+		return fmt.Sprintf("ref_%d", itemID)
+	}
+	return fmt.Sprintf("node_%d", itemID)
+}
+
+// Returns the label for an item, as a list of lines
+func (tree *Critbit[T]) itemLabel(item *structureItem, showValues bool) []string {
+	switch {
+	case item.truncated:
+		return []string{fmt.Sprintf("%d keys", item.numKeys)}
+	case item.itemType == kChildIntNode:
+		node := &tree.internalNodes[item.itemID]
+		return []string{fmt.Sprintf("off=%d", node.offset), "bit=" + bitLabel(node.bit),
+			fmt.Sprintf("nodeNum=%d", item.itemID)}
+	case item.itemType == kChildExtRef:
+		lines := []string{fmt.Sprintf("%q", tree.refOriginalKey(item.itemID)),
+			fmt.Sprintf("refNum=%d", item.itemID)}
+		if showValues {
+			lines = append(lines, fmt.Sprintf("value=%v", tree.externalRefs[item.itemID].value))
+		}
+		return lines
+	case item.itemType == kChildNil:
+		return []string{"nil"}
+	}
+	return []string{fmt.Sprintf("unexpected type %d", item.itemType)}
+}
+
+// Returns how a node's bit is shown by the exporters. The end of keys
+// which end partway through a byte is shown with the number of bits of
+// the byte which they have.
+func bitLabel(bit byte) string {
+	switch {
+	case bit == kEndOfKey:
+		return "end"
+	case isEndOfKey(bit):
+		return fmt.Sprintf("end+%d", bitIndex(bit))
+	}
+	return fmt.Sprintf("0x%02x", bit)
+}
+
+// An errWriter remembers the first error from writing to a writer, and
+// writes nothing after it, so that callers can check for errors once.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (out *errWriter) printf(format string, args ...any) {
+	if out.err != nil {
+		return
+	}
+	_, out.err = fmt.Fprintf(out.w, format, args...)
+}
+
+func (out *errWriter) fail(err error) {
+	if out.err == nil {
+		out.err = err
+	}
+}
+
+// WriteMermaid writes the tree structure to w as a Mermaid flowchart,
+// which Markdown renderers like GitHub's can draw in a ```mermaid block.
+func (tree *Critbit[T]) WriteMermaid(w io.Writer, options ExportOptions) error {
+	out := &errWriter{w: w}
+	highlighted := tree.highlightedItems(options.HighlightKeys)
+	var highlightedNames []string
+	var highlightedEdges []string
+	numEdges := 0
+
+	out.printf("flowchart TD\n")
+	tree.walkStructure(options.MaxDepth, func(item *structureItem) {
+		name := item.name()
+		label := mermaidQuote(tree.itemLabel(item, options.ShowValues))
+		switch {
+		case item.truncated:
+			out.printf("    %s[[%s]]\n", name, label)
+		case item.itemType == kChildIntNode:
+			out.printf("    %s[%s]\n", name, label)
+		case item.itemType == kChildExtRef:
+			out.printf("    %s(%s)\n", name, label)
+		default:
+			out.printf("    %s((%s))\n", name, label)
+		}
+		if highlighted[name] {
+			highlightedNames = append(highlightedNames, name)
+		}
+		if item.parent == nil {
+			return
+		}
+		// An edge is highlighted if both of its ends are
+		parentName := item.parent.name()
+		if highlighted[parentName] && highlighted[name] {
+			highlightedEdges = append(highlightedEdges, strconv.Itoa(numEdges))
+		}
+		out.printf("    %s -->|%d| %s\n", parentName, item.direction, name)
+		numEdges++
+	}, nil)
+	if len(highlightedNames) > 0 {
+		out.printf("    classDef highlighted stroke:#f00,stroke-width:2px\n")
+		out.printf("    class %s highlighted\n", strings.Join(highlightedNames, ","))
+	}
+	if len(highlightedEdges) > 0 {
+		out.printf("    linkStyle %s stroke:#f00,stroke-width:2px\n", strings.Join(highlightedEdges, ","))
+	}
+	return out.err
+}
+
+// Quotes the lines of a label for Mermaid. Characters which Mermaid
+// would take as markup are written as entity codes.
+func mermaidQuote(lines []string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteString("<br/>")
+		}
+		for _, r := range line {
+			switch r {
+			case '"':
+				sb.WriteString("#quot;")
+			case '#', '<', '>', '&', '`':
+				fmt.Fprintf(&sb, "#%d;", r)
+			default:
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// A jsonItem is an internal node or an external ref, as written by
+// WriteJSON.
+type jsonItem struct {
+	Type        string    `json:"type"` // "node", "ref", "nil", or "truncated"
+	NodeNum     *int64    `json:"nodeNum,omitempty"`
+	Offset      *int      `json:"offset,omitempty"`
+	Bit         string    `json:"bit,omitempty"`
+	Left        *jsonItem `json:"left,omitempty"`
+	Right       *jsonItem `json:"right,omitempty"`
+	NumKeys     int       `json:"numKeys,omitempty"`
+	RefNum      *int64    `json:"refNum,omitempty"`
+	Key         *string   `json:"key,omitempty"`
+	KeyBase64   string    `json:"keyBase64,omitempty"` // set if the key isn't valid UTF-8
+	Value       any       `json:"value,omitempty"`
+	Highlighted bool      `json:"highlighted,omitempty"`
+}
+
+// WriteJSON writes the tree structure to w as a nested JSON document. The
+// document is an object with the tree's length and its root item; each
+// item has a "type" of "node", "ref", "nil" or "truncated", and internal
+// nodes have "left" and "right" children. Keys which aren't valid UTF-8
+// are also given in base64.
+func (tree *Critbit[T]) WriteJSON(w io.Writer, options ExportOptions) error {
+	highlighted := tree.highlightedItems(options.HighlightKeys)
+	document := struct {
+		Length int       `json:"length"`
+		Root   *jsonItem `json:"root,omitempty"`
+	}{Length: tree.numExternalRefs}
+	var stack []*jsonItem
+	var err error
+
+	tree.walkStructure(options.MaxDepth, func(item *structureItem) {
+		jItem := &jsonItem{Highlighted: highlighted[item.name()]}
+		id := int64(item.itemID)
+		switch {
+		case item.truncated:
+			jItem.Type = "truncated"
+			jItem.NodeNum = &id
+			jItem.NumKeys = item.numKeys
+		case item.itemType == kChildIntNode:
+			node := &tree.internalNodes[item.itemID]
+			offset := int(node.offset)
+			jItem.Type = "node"
+			jItem.NodeNum = &id
+			jItem.Offset = &offset
+			jItem.Bit = bitLabel(node.bit)
+		case item.itemType == kChildExtRef:
+			key := tree.refOriginalKey(item.itemID)
+			jItem.Type = "ref"
+			jItem.RefNum = &id
+			jItem.Key = &key
+			if !utf8.ValidString(key) {
+				jItem.KeyBase64 = base64.StdEncoding.EncodeToString([]byte(key))
+			}
+			if options.ShowValues {
+				jItem.Value = tree.externalRefs[item.itemID].value
+			}
+		case item.itemType == kChildNil:
+			jItem.Type = "nil"
+		default:
+			if err == nil {
+				err = errors.Errorf("Node %d has unexpected child type %d in direction %d",
+					item.parent.itemID, item.itemType, item.direction)
+			}
+			return
+		}
+
+		if len(stack) == 0 {
+			document.Root = jItem
+		} else if parent := stack[len(stack)-1]; item.direction == kDirectionLeft {
+			parent.Left = jItem
+		} else {
+			parent.Right = jItem
+		}
+		if item.itemType == kChildIntNode && !item.truncated {
+			stack = append(stack, jItem)
+		}
+	}, func(*structureItem) {
+		stack = stack[:len(stack)-1]
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return errors.Wrap(encoder.Encode(document), "Writing JSON")
+}
+
+// WriteASCII writes the tree structure to w as a tree drawn with
+// box-drawing characters, for terminals. Highlighted items are marked
+// with an asterisk.
+func (tree *Critbit[T]) WriteASCII(w io.Writer, options ExportOptions) error {
+	out := &errWriter{w: w}
+	highlighted := tree.highlightedItems(options.HighlightKeys)
+
+	tree.walkStructure(options.MaxDepth, func(item *structureItem) {
+		// The prefix continues the lines of the ancestors which have
+		// siblings below this item.
+		var prefix string
+		if item.parent != nil {
+			prefix = [2]string{"├── ", "└── "}[item.direction]
+			for ancestor := item.parent; ancestor.parent != nil; ancestor = ancestor.parent {
+				prefix = [2]string{"│   ", "    "}[ancestor.direction] + prefix
+			}
+		}
+		label := strings.Join(tree.itemLabel(item, options.ShowValues), " ")
+		if item.truncated {
+			label = fmt.Sprintf("nodeNum=%d (%s)", item.itemID, label)
+		}
+		if highlighted[item.name()] {
+			label += " *"
+		}
+		out.printf("%s%s\n", prefix, label)
+	}, nil)
+	return out.err
+}
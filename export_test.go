@@ -0,0 +1,139 @@
+package critbit
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestWriteMermaid(c *C) {
+	tree := newDumpTestTree(c)
+	var buf bytes.Buffer
+	err := tree.WriteMermaid(&buf, ExportOptions{HighlightKeys: []string{"a\x00"}})
+	c.Assert(err, IsNil)
+	c.Check(buf.String(), Equals, `flowchart TD
+    node_0["off=0<br/>bit=0x10<br/>nodeNum=0"]
+    node_1["off=0<br/>bit=0x02<br/>nodeNum=1"]
+    node_0 -->|0| node_1
+    node_2["off=1<br/>bit=end<br/>nodeNum=2"]
+    node_1 -->|0| node_2
+    ref_0("#quot;a#quot;<br/>refNum=0")
+    node_2 -->|0| ref_0
+    ref_3("#quot;a\x00#quot;<br/>refNum=3")
+    node_2 -->|1| ref_3
+    ref_2("#quot;back\\slash#quot;<br/>refNum=2")
+    node_1 -->|1| ref_2
+    ref_1("#quot;say \#quot;hi\#quot;#quot;<br/>refNum=1")
+    node_0 -->|1| ref_1
+    classDef highlighted stroke:#f00,stroke-width:2px
+    class node_0,node_1,node_2,ref_3 highlighted
+    linkStyle 0,1,3 stroke:#f00,stroke-width:2px
+`)
+
+	c.Check(mermaidQuote([]string{"<a href=#>`&`</a>"}), Equals,
+		`"#60;a href=#35;#62;#96;#38;#96;#60;/a#62;"`)
+}
+
+func (s *MySuite) TestWriteJSON(c *C) {
+	tree := newDumpTestTree(c)
+	_, err := tree.Insert("\xff", 4)
+	c.Assert(err, IsNil)
+
+	var buf bytes.Buffer
+	err = tree.WriteJSON(&buf, ExportOptions{ShowValues: true, MaxDepth: 2})
+	c.Assert(err, IsNil)
+
+	var document struct {
+		Length int
+		Root   *jsonItem
+	}
+	c.Assert(json.Unmarshal(buf.Bytes(), &document), IsNil)
+	c.Check(document.Length, Equals, 5)
+
+	root := document.Root
+	c.Assert(root, NotNil)
+	c.Check(root.Type, Equals, "node")
+	c.Check(*root.Offset, Equals, 0)
+	c.Check(root.Bit, Equals, "0x80")
+
+	// The key which isn't valid UTF-8 is given in base64
+	right := root.Right
+	c.Check(right.Type, Equals, "ref")
+	c.Check(right.KeyBase64, Equals, "/w==")
+	c.Check(right.Value, Equals, float64(4))
+
+	sayHi := root.Left.Right
+	c.Check(sayHi.Type, Equals, "ref")
+	c.Check(*sayHi.Key, Equals, `say "hi"`)
+	c.Check(sayHi.KeyBase64, Equals, "")
+
+	// Below the maximum depth, subtrees are summarized
+	truncated := root.Left.Left.Left
+	c.Check(truncated.Type, Equals, "truncated")
+	c.Check(truncated.NumKeys, Equals, 2)
+	c.Check(truncated.Left, IsNil)
+
+	// An empty tree has no root
+	buf.Reset()
+	c.Assert(New[int](0).WriteJSON(&buf, ExportOptions{}), IsNil)
+	c.Check(buf.String(), Equals, "{\n  \"length\": 0\n}\n")
+}
+
+func (s *MySuite) TestWriteJSONError(c *C) {
+	tree := New[chan int](0)
+	_, err := tree.Insert("a", make(chan int))
+	c.Assert(err, IsNil)
+
+	var buf bytes.Buffer
+	c.Check(tree.WriteJSON(&buf, ExportOptions{}), IsNil)
+	c.Check(tree.WriteJSON(&buf, ExportOptions{ShowValues: true}), ErrorMatches, "Writing JSON: .*")
+	c.Check(tree.WriteJSON(&failingWriter{remaining: 10}, ExportOptions{}), NotNil)
+}
+
+func (s *MySuite) TestWriteASCII(c *C) {
+	tree := newDumpTestTree(c)
+	var buf bytes.Buffer
+	err := tree.WriteASCII(&buf, ExportOptions{HighlightKeys: []string{"a"}})
+	c.Assert(err, IsNil)
+	c.Check(buf.String(), Equals, `off=0 bit=0x10 nodeNum=0 *
+├── off=0 bit=0x02 nodeNum=1 *
+│   ├── off=1 bit=end nodeNum=2 *
+│   │   ├── "a" refNum=0 *
+│   │   └── "a\x00" refNum=3
+│   └── "back\\slash" refNum=2
+└── "say \"hi\"" refNum=1
+`)
+
+	buf.Reset()
+	err = tree.WriteASCII(&buf, ExportOptions{ShowValues: true, MaxDepth: 1})
+	c.Assert(err, IsNil)
+	c.Check(buf.String(), Equals, `off=0 bit=0x10 nodeNum=0
+├── off=0 bit=0x02 nodeNum=1
+│   ├── nodeNum=2 (2 keys)
+│   └── "back\\slash" refNum=2 value=2
+└── "say \"hi\"" refNum=1 value=1
+`)
+
+	c.Check(tree.WriteASCII(&failingWriter{remaining: 30}, ExportOptions{}), NotNil)
+}
+
+// All the exporters agree on which items the tree has
+func (s *MySuite) TestExportersAgree(c *C) {
+	tree := New[int](0)
+	for i, key := range []string{"alpha", "alphabet", "beta", "gamma", "", "al"} {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+	}
+	var dot, mermaid, ascii bytes.Buffer
+	c.Assert(tree.WriteDot(&dot, ExportOptions{}), IsNil)
+	c.Assert(tree.WriteMermaid(&mermaid, ExportOptions{}), IsNil)
+	c.Assert(tree.WriteASCII(&ascii, ExportOptions{}), IsNil)
+
+	c.Check(strings.Count(dot.String(), " -> "), Equals, 2*tree.numInternalNodes)
+	c.Check(strings.Count(mermaid.String(), " -->|"), Equals, 2*tree.numInternalNodes)
+	c.Check(strings.Count(ascii.String(), "\n"), Equals, tree.numInternalNodes+tree.numExternalRefs)
+	c.Check(strings.Count(ascii.String(), "refNum="), Equals, tree.numExternalRefs)
+}
@@ -0,0 +1,128 @@
+package critbit
+
+import (
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Splits fuzzer input into keys. Each key is a length byte followed by
+// up to 7 bytes, so the keys are short and often share prefixes, and
+// contain plenty of NULs.
+func fuzzKeys(data []byte) []string {
+	var keys []string
+	for len(data) > 0 {
+		n := min(int(data[0]%8), len(data)-1)
+		keys = append(keys, string(data[1:1+n]))
+		data = data[1+n:]
+	}
+	return keys
+}
+
+func fuzzSeed(keys ...string) []byte {
+	var data []byte
+	for _, key := range keys {
+		data = append(data, byte(len(key)))
+		data = append(data, key...)
+	}
+	return data
+}
+
+func checkFuzzTree(t *testing.T, tree *Critbit[int], model map[string]int) {
+	if tree.Length() != len(model) {
+		t.Fatalf("Length() = %d, expected %d", tree.Length(), len(model))
+	}
+	expectedKeys := make([]string, 0, len(model))
+	for key, expected := range model {
+		expectedKeys = append(expectedKeys, key)
+		value, found := tree.Get(key)
+		if !found || value != expected {
+			t.Fatalf("Get(%q) = %d, %v; expected %d", key, value, found, expected)
+		}
+	}
+	slices.Sort(expectedKeys)
+	if keys := tree.Keys(); !slices.Equal(keys, expectedKeys) {
+		t.Fatalf("Keys() = %q, expected %q", keys, expectedKeys)
+	}
+}
+
+func FuzzInsertDelete(f *testing.F) {
+	f.Add(fuzzSeed("a", "a\x00"))
+	f.Add(fuzzSeed("", "\x00", "\x00\x00", "\x00\x01"))
+	f.Add(fuzzSeed("a\x00b", "a", "ab", "a\x00", "a"))
+	f.Add(fuzzSeed("apple", "app", "apply", "ape", "app\x00"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		keys := fuzzKeys(data)
+		tree := New[int](0)
+		model := make(map[string]int)
+		for i, key := range keys {
+			inModel := hasKey(model, key)
+			ok, err := tree.Insert(key, i)
+			if err != nil {
+				t.Fatalf("Insert(%q): %v", key, err)
+			}
+			if ok == inModel {
+				t.Fatalf("Insert(%q) = %v with the key already present = %v", key, ok, inModel)
+			}
+			if ok {
+				model[key] = i
+			}
+		}
+		checkFuzzTree(t, tree, model)
+
+		// Delete every other key, including ones that were never inserted
+		for i, key := range keys {
+			if i%2 == 1 {
+				continue
+			}
+			for _, k := range []string{key + "\x00", key} {
+				inModel := hasKey(model, k)
+				if tree.Delete(k) != inModel {
+					t.Fatalf("Delete(%q) = %v, expected %v", k, !inModel, inModel)
+				}
+				delete(model, k)
+			}
+		}
+		checkFuzzTree(t, tree, model)
+	})
+}
+
+func hasKey(model map[string]int, key string) bool {
+	_, found := model[key]
+	return found
+}
+
+func FuzzPrefixes(f *testing.F) {
+	f.Add(fuzzSeed("a", "a\x00", "a\x00\x00", "ab"), "a\x00")
+	f.Add(fuzzSeed("", "\x00", "\x00\x00"), "\x00\x00\x00")
+	f.Fuzz(func(t *testing.T, data []byte, query string) {
+		tree := New[int](0)
+		for i, key := range fuzzKeys(data) {
+			tree.Insert(key, i)
+		}
+		var expectedExtensions, expectedPrefixes []string
+		for _, key := range tree.Keys() {
+			if strings.HasPrefix(key, query) {
+				expectedExtensions = append(expectedExtensions, key)
+			}
+			if strings.HasPrefix(query, key) {
+				expectedPrefixes = append(expectedPrefixes, key)
+			}
+		}
+		if extensions := collectKeys(tree.IteratePrefix(query)); !slices.Equal(extensions, expectedExtensions) {
+			t.Fatalf("IteratePrefix(%q) = %q, expected %q", query, extensions, expectedExtensions)
+		}
+		if prefixes := collectKeys(tree.PrefixesOf(query)); !slices.Equal(prefixes, expectedPrefixes) {
+			t.Fatalf("PrefixesOf(%q) = %q, expected %q", query, prefixes, expectedPrefixes)
+		}
+	})
+}
+
+func collectKeys(items iter.Seq2[string, int]) []string {
+	var keys []string
+	for key := range items {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,115 @@
+package critbit
+
+import (
+	"iter"
+)
+
+// A FuzzyMatch is yielded by FuzzySearch for each key it finds.
+type FuzzyMatch[T any] struct {
+	Value    T
+	Distance int // the Levenshtein distance from the query
+}
+
+// FuzzySearch returns an iterator over the keys which are within maxEdits
+// insertions, deletions or substitutions of the query, in sorted order.
+// Each key is yielded with its value and its distance from the query.
+// The distance counts bytes, not runes, so changing one multi-byte
+// character counts as more than one edit.
+//
+// Every key in a subtree shares the bytes before the subtree's critical
+// offset, so the search stops descending into a subtree as soon as the
+// edits needed for that shared prefix exceed maxEdits.
+func (tree *Critbit[T]) FuzzySearch(query string, maxEdits int) iter.Seq2[string, FuzzyMatch[T]] {
+	return func(yield func(string, FuzzyMatch[T]) bool) {
+		if tree.numExternalRefs == 0 || maxEdits < 0 {
+			return
+		}
+		search := &fuzzySearch[T]{
+			tree:     tree,
+			query:    tree.collate(query),
+			maxEdits: maxEdits,
+			yield:    yield,
+		}
+		// The first row is the distance from the empty prefix
+		search.rows = [][]int{make([]int, len(search.query)+1)}
+		for i := range search.rows[0] {
+			search.rows[0][i] = i
+		}
+		rootType := tree.rootItemType()
+		leftmost := tree.refKey(tree.leftmostRef(rootType, tree.rootItem))
+		search.walkItem(rootType, tree.rootItem, leftmost, 0)
+	}
+}
+
+type fuzzySearch[T any] struct {
+	tree     *Critbit[T]
+	query    string
+	maxEdits int
+	yield    func(string, FuzzyMatch[T]) bool
+
+	// rows[i] holds the edit distances between the first i bytes of
+	// the current key and each prefix of the query.
+	rows [][]int
+}
+
+// Computes rows up to row len(prefix), re-using the rows which were
+// computed from the same bytes. Returns the smallest distance in the
+// last row.
+func (search *fuzzySearch[T]) extendRows(prefix string, from int) int {
+	for i := from; i < len(prefix); i++ {
+		if i+1 == len(search.rows) {
+			search.rows = append(search.rows, make([]int, len(search.query)+1))
+		}
+		prev := search.rows[i]
+		row := search.rows[i+1]
+		row[0] = i + 1
+		for j := 1; j <= len(search.query); j++ {
+			cost := 1
+			if search.query[j-1] == prefix[i] {
+				cost = 0
+			}
+			row[j] = min(prev[j]+1, row[j-1]+1, prev[j-1]+cost)
+		}
+	}
+	best := search.rows[len(prefix)][0]
+	for _, distance := range search.rows[len(prefix)] {
+		best = min(best, distance)
+	}
+	return best
+}
+
+// Walks one item, whose rows are already valid for the first 'depth'
+// bytes. The leftmost key of the item is passed in; the left child
+// shares it, so only the right child needs to look for its own.
+// Returns false if the caller stopped the iteration.
+func (search *fuzzySearch[T]) walkItem(itemType byte, itemID nodeIndex, leftmost string, depth int) bool {
+	tree := search.tree
+	if itemType == kChildExtRef {
+		key := tree.refKey(itemID)
+		search.extendRows(key, min(depth, len(key)))
+		distance := search.rows[len(key)][len(search.query)]
+		if distance > search.maxEdits {
+			return true
+		}
+		return search.yield(tree.refOriginalKey(itemID), FuzzyMatch[T]{
+			Value:    tree.externalRefs[itemID].value,
+			Distance: distance,
+		})
+	}
+
+	node := &tree.internalNodes[itemID]
+	prefix := leftmost[:min(int(node.offset), len(leftmost))]
+	if search.extendRows(prefix, min(depth, len(prefix))) > search.maxEdits {
+		// No key in this subtree can be close enough
+		return true
+	}
+	depth = len(prefix)
+
+	leftType := node.getChildType(kDirectionLeft)
+	if !search.walkItem(leftType, node.child[kDirectionLeft], leftmost, depth) {
+		return false
+	}
+	rightType := node.getChildType(kDirectionRight)
+	leftmost = tree.refKey(tree.leftmostRef(rightType, node.child[kDirectionRight]))
+	return search.walkItem(rightType, node.child[kDirectionRight], leftmost, depth)
+}
@@ -0,0 +1,91 @@
+package critbit
+
+import (
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func levenshtein(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 0; i < len(a); i++ {
+		row := make([]int, len(b)+1)
+		row[0] = i + 1
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i] == b[j-1] {
+				cost = 0
+			}
+			row[j] = min(prev[j]+1, row[j-1]+1, prev[j-1]+cost)
+		}
+		prev = row
+	}
+	return prev[len(b)]
+}
+
+var fuzzyTable = []string{"", "a", "apple", "apply", "ape", "grape", "gray", "grey",
+	"green", "green boy", "gremlin", "greet", "zoo", "zoom", "kitten", "sitting", "mitten"}
+
+func (s *MySuite) TestFuzzySearch(c *C) {
+	tree := New[int](0)
+	for i, key := range fuzzyTable {
+		tree.Insert(key, i)
+	}
+
+	for _, query := range []string{"", "a", "appel", "gren", "kitten", "zzz", "greenboy"} {
+		for maxEdits := 0; maxEdits <= 3; maxEdits++ {
+			var expected []string
+			for _, key := range tree.Keys() {
+				if levenshtein(key, query) <= maxEdits {
+					expected = append(expected, key)
+				}
+			}
+			var found []string
+			for key, match := range tree.FuzzySearch(query, maxEdits) {
+				found = append(found, key)
+				c.Check(match.Distance, Equals, levenshtein(key, query))
+				value, _ := tree.Get(key)
+				c.Check(match.Value, Equals, value)
+			}
+			c.Check(found, DeepEquals, expected, Commentf("query=%q maxEdits=%d", query, maxEdits))
+		}
+	}
+
+	// Stop early
+	var found []string
+	for key := range tree.FuzzySearch("gray", 2) {
+		found = append(found, key)
+		if len(found) == 2 {
+			break
+		}
+	}
+	c.Check(found, DeepEquals, []string{"grape", "gray"})
+}
+
+func (s *MySuite) TestFuzzySearchSmallTrees(c *C) {
+	tree := New[int](0)
+	for range tree.FuzzySearch("x", 1) {
+		c.Fail()
+	}
+	tree.Insert("x", 1)
+	var found []string
+	for key, match := range tree.FuzzySearch("", 1) {
+		found = append(found, key)
+		c.Check(match.Distance, Equals, 1)
+	}
+	c.Check(found, DeepEquals, []string{"x"})
+}
+
+func (s *MySuite) TestFuzzySearchCollation(c *C) {
+	tree := New[int](0, WithCollation(FoldASCII))
+	tree.Insert("Alice", 1)
+	tree.Insert("Bob", 2)
+	var found []string
+	for key, match := range tree.FuzzySearch("ALICIA", 2) {
+		found = append(found, key)
+		c.Check(match.Distance, Equals, 2)
+	}
+	c.Check(found, DeepEquals, []string{"Alice"})
+}
@@ -1,12 +1,11 @@
 package critbit
 
-import "strings"
-
 // Get finds the key and returns its value. The boolean
 // indicates if it was found or not.
 func (tree *Critbit[T]) Get(key string) (T, bool) {
 	var nilVal T
-	has, refNum := tree.findRef(key)
+	key = tree.collate(key)
+	has, refNum := tree.findRef(key, bitLen(key))
 	if !has {
 		return nilVal, false
 	}
@@ -16,55 +15,109 @@ func (tree *Critbit[T]) Get(key string) (T, bool) {
 // Returns the first key that starts with a string, and returns
 // the KeyValueTuple, or nil
 func (tree *Critbit[T]) GetHasPrefix(key string) *KeyValueTuple[T] {
-
-	has, refNum := tree.findRef(key)
+	key = tree.collate(key)
+	has, refNum := tree.findRef(key, bitLen(key))
 
 	if !has {
+		if tree.numExternalRefs == 0 {
+			return nil
+		}
 		// Not an exact match, but, did we find something that does start
 		// with our string?
-		foundRefKey := tree.externalRefs[refNum].key
-		if !strings.HasPrefix(foundRefKey, key) {
+		if !tree.refHasPrefix(refNum, key, bitLen(key)) {
 			// No, the best ref does not start with the user's key
 			return nil
 		}
 		// keep going!
 	}
 	return &KeyValueTuple[T]{
-		Key:   tree.externalRefs[refNum].key,
+		Key:   tree.refOriginalKey(refNum),
 		Value: tree.externalRefs[refNum].value,
 	}
 }
 
 // Returns: found?, refNum
-func (tree *Critbit[T]) findRef(key string) (bool, uint32) {
+func (tree *Critbit[T]) findRef(key string, keyBits int64) (bool, nodeIndex) {
 	// Is the tree empty? Nothing to find.
-	if len(tree.externalRefs) == 0 {
+	if tree.numExternalRefs == 0 {
 		return false, 0
 	}
 
 	// Find the best external reference
-	bestRefNum := tree.findBestExternalReference(key)
+	bestRefNum := tree.findBestExternalReference(key, keyBits)
 
 	// find critical bit, but more importantly, is there
 	// an identical match?
-	identical, _, _, _ := tree.findCriticalBit(bestRefNum, key)
+	identical, _, _, _ := tree.findCriticalBit(bestRefNum, key, keyBits)
 
 	return identical, bestRefNum
 }
 
 // Returns identicalMatch?, refNum, parentNodeNum, parentDirection
-func (tree *Critbit[T]) findRefWithAncestry(key string) (bool, uint32, uint32, byte) {
+func (tree *Critbit[T]) findRefWithAncestry(key string, keyBits int64) (bool, nodeIndex, nodeIndex, byte) {
 	// Is the tree empty? Nothing to find.
-	if len(tree.externalRefs) == 0 {
+	if tree.numExternalRefs == 0 {
 		return false, 0, 0, 0
 	}
 
 	// Find the best external reference
-	bestRefNum, _, _, parentNodeNum, parentDirection, _ := tree.findBestExternalReferenceWithAncestry(key)
+	bestRefNum, _, _, parentNodeNum, parentDirection, _ := tree.findBestExternalReferenceWithAncestry(key, keyBits)
 
 	// find critical bit, but more importantly, is there
 	// an identical match?
-	identical, _, _, _ := tree.findCriticalBit(bestRefNum, key)
+	identical, _, _, _ := tree.findCriticalBit(bestRefNum, key, keyBits)
 
 	return identical, bestRefNum, parentNodeNum, parentDirection
 }
+
+// Returns the type and ID of the item under which are all the keys that
+// start with the prefix, of prefixBits bits, and whether there are any
+// such keys.
+func (tree *Critbit[T]) findPrefixRoot(prefix string, prefixBits int64) (byte, nodeIndex, bool) {
+	itemType := tree.rootItemType()
+	itemID := tree.rootItem
+	if itemType == kChildNil {
+		return 0, 0, false
+	}
+
+	// The keys which start with the prefix all go the same way as the
+	// prefix at every node which tests a bit within the prefix.
+	for itemType == kChildIntNode {
+		node := &tree.internalNodes[itemID]
+		if node.position() >= prefixBits {
+			break
+		}
+		direction := node.direction(prefix, prefixBits)
+		itemType = node.getChildType(direction)
+		itemID = node.child[direction]
+	}
+
+	// The keys under the item all share their first prefixBits bits,
+	// so either all of them start with the prefix, or none do.
+	if !tree.refHasPrefix(tree.leftmostRef(itemType, itemID), prefix, prefixBits) {
+		return 0, 0, false
+	}
+	return itemType, itemID, true
+}
+
+// Returns whether the key of a ref starts with a prefix of prefixBits bits
+func (tree *Critbit[T]) refHasPrefix(refNum nodeIndex, prefix string, prefixBits int64) bool {
+	key := tree.refKey(refNum)
+	return hasBitPrefix(key, tree.refBits(refNum, key), prefix, prefixBits)
+}
+
+// Returns whether a key of keyBits bits starts with a prefix of
+// prefixBits bits. Only the bits within the lengths are compared.
+func hasBitPrefix(key string, keyBits int64, prefix string, prefixBits int64) bool {
+	if keyBits < prefixBits {
+		return false
+	}
+	n := prefixBits / 8
+	if key[:n] != prefix[:n] {
+		return false
+	}
+	if prefixBits%8 == 0 {
+		return true
+	}
+	return (key[n]^prefix[n])&(0xff<<(8-prefixBits%8)) == 0
+}
@@ -58,3 +58,19 @@ func (s *MySuite) TestGet01(c *C) {
 	c.Assert(kvt, NotNil)
 	c.Assert(kvt.Key, Equals, "apple")
 }
+
+// A tree whose keys were all deleted still has their slots, but is empty
+func (s *MySuite) TestGetEmptied(c *C) {
+	trie := New[int](0)
+	c.Check(trie.GetHasPrefix(""), IsNil)
+
+	_, err := trie.Insert("a", 1)
+	c.Assert(err, IsNil)
+	c.Check(trie.Delete("a"), Equals, true)
+
+	_, found := trie.Get("a")
+	c.Check(found, Equals, false)
+	c.Check(trie.GetHasPrefix(""), IsNil)
+	c.Check(trie.Update("a", 2), Equals, false)
+	c.Check(trie.Delete("a"), Equals, false)
+}
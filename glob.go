@@ -0,0 +1,294 @@
+package critbit
+
+import (
+	"iter"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// A Glob is a compiled glob pattern, which MatchGlob matches against the
+// keys of a tree. In the pattern:
+//
+//	'*'         matches any sequence of characters, including none
+//	'?'         matches any single character
+//	'[' class ']'  matches a character in the class, such as [abc] or
+//	            [a-z]; the class is negated if it starts with '!' or '^'
+//	'\' c       matches the character c
+//
+// Any other character matches itself. Unlike path.Match, '*' also matches
+// '/'.
+type Glob struct {
+	pattern string
+	tokens  glob
+}
+
+// CompileGlob parses a glob pattern. An error is returned if the pattern
+// is malformed.
+func CompileGlob(pattern string) (*Glob, error) {
+	tokens, err := parseGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &Glob{pattern: pattern, tokens: tokens}, nil
+}
+
+// String returns the pattern that the Glob was compiled from.
+func (g *Glob) String() string {
+	return g.pattern
+}
+
+// MustMatch returns an iterator over the keys that match a glob pattern,
+// in sorted order; see Glob for the syntax. Like regexp.MustCompile, it
+// panics if the pattern is malformed, so it is meant for patterns which
+// are constants in a program. Patterns which come from users should be
+// compiled with CompileGlob, and matched with MatchGlob, to get the error
+// instead.
+func (tree *Critbit[T]) MustMatch(pattern string) iter.Seq2[string, T] {
+	g, err := CompileGlob(pattern)
+	if err != nil {
+		panic(err.Error())
+	}
+	return tree.MatchGlob(g)
+}
+
+// MatchGlob returns an iterator over the keys that match a compiled glob
+// pattern, in sorted order. The literal prefix of the pattern, before its
+// first wildcard, selects the subtree to walk, so only the keys with that
+// prefix are tested against the rest of the pattern. If the tree has a
+// collation, the literal characters and the ends of the character ranges
+// are collated, and matched against the collated keys.
+func (tree *Critbit[T]) MatchGlob(g *Glob) iter.Seq2[string, T] {
+	tokens := g.tokens
+	if tree.config.collation != nil {
+		tokens = tokens.collate(tree.collate)
+	}
+	return func(yield func(string, T) bool) {
+		prefix := tokens.literalPrefix()
+		itemType, itemID, found := tree.findPrefixRoot(prefix, bitLen(prefix))
+		if !found {
+			return
+		}
+		tree.walkRefs(itemType, itemID, func(refNum nodeIndex) bool {
+			if !tokens.match(tree.refKey(refNum)) {
+				return true
+			}
+			return yield(tree.refOriginalKey(refNum), tree.externalRefs[refNum].value)
+		})
+	}
+}
+
+const (
+	kGlobLiteral = iota
+	kGlobStar
+	kGlobAny
+	kGlobClass
+)
+
+type globToken struct {
+	kind    int
+	literal string      // kGlobLiteral
+	ranges  []runeRange // kGlobClass
+	negated bool        // kGlobClass
+}
+
+type runeRange struct {
+	lo, hi rune
+}
+
+type glob []globToken
+
+func parseGlob(pattern string) (glob, error) {
+	var tokens glob
+	var literal strings.Builder
+	flushLiteral := func() {
+		if literal.Len() > 0 {
+			tokens = append(tokens, globToken{kind: kGlobLiteral, literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(pattern); {
+		switch pattern[i] {
+		case '*':
+			flushLiteral()
+			// Consecutive stars are the same as one star
+			if len(tokens) == 0 || tokens[len(tokens)-1].kind != kGlobStar {
+				tokens = append(tokens, globToken{kind: kGlobStar})
+			}
+			i++
+		case '?':
+			flushLiteral()
+			tokens = append(tokens, globToken{kind: kGlobAny})
+			i++
+		case '[':
+			flushLiteral()
+			token, n, err := parseGlobClass(pattern[i:])
+			if err != nil {
+				return nil, errors.Wrapf(err, "Bad pattern %q", pattern)
+			}
+			tokens = append(tokens, token)
+			i += n
+		case '\\':
+			if i+1 == len(pattern) {
+				return nil, errors.Errorf("Bad pattern %q: trailing backslash", pattern)
+			}
+			literal.WriteByte(pattern[i+1])
+			i += 2
+		default:
+			literal.WriteByte(pattern[i])
+			i++
+		}
+	}
+	flushLiteral()
+	return tokens, nil
+}
+
+// Parses the character class at the start of the pattern. Returns the
+// token, and the number of bytes of the pattern which it uses.
+func parseGlobClass(pattern string) (globToken, int, error) {
+	token := globToken{kind: kGlobClass}
+	i := 1
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		token.negated = true
+		i++
+	}
+
+	// Reads one, possibly escaped, character of the class
+	readRune := func() (rune, error) {
+		if i < len(pattern) && pattern[i] == '\\' {
+			i++
+		}
+		if i == len(pattern) {
+			return 0, errors.Errorf("unterminated character class")
+		}
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		return r, nil
+	}
+
+	for first := true; ; first = false {
+		if i == len(pattern) {
+			return token, 0, errors.Errorf("unterminated character class")
+		}
+		// A ']' right after the '[' is part of the class
+		if pattern[i] == ']' && !first {
+			return token, i + 1, nil
+		}
+		lo, err := readRune()
+		if err != nil {
+			return token, 0, err
+		}
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			i++
+			if hi, err = readRune(); err != nil {
+				return token, 0, err
+			}
+			if hi < lo {
+				return token, 0, errors.Errorf("character range %q-%q is out of order", lo, hi)
+			}
+		}
+		token.ranges = append(token.ranges, runeRange{lo, hi})
+	}
+}
+
+// Returns a copy of the tokens with their literals collated. The ends of
+// a character range are collated if each collates to one character, and
+// they stay in order; otherwise the range is kept as it is.
+func (g glob) collate(collate func(string) string) glob {
+	collated := make(glob, len(g))
+	for i, token := range g {
+		switch token.kind {
+		case kGlobLiteral:
+			token.literal = collate(token.literal)
+		case kGlobClass:
+			ranges := make([]runeRange, len(token.ranges))
+			for j, rr := range token.ranges {
+				lo, loOK := collateRune(collate, rr.lo)
+				hi, hiOK := collateRune(collate, rr.hi)
+				if loOK && hiOK && lo <= hi {
+					rr = runeRange{lo, hi}
+				}
+				ranges[j] = rr
+			}
+			token.ranges = ranges
+		}
+		collated[i] = token
+	}
+	return collated
+}
+
+// Returns the character which a character collates to, if it is one
+func collateRune(collate func(string) string, r rune) (rune, bool) {
+	s := collate(string(r))
+	collated, size := utf8.DecodeRuneInString(s)
+	return collated, size > 0 && size == len(s) && collated != utf8.RuneError
+}
+
+// The bytes at the start of every key which matches the pattern
+func (g glob) literalPrefix() string {
+	if len(g) > 0 && g[0].kind == kGlobLiteral {
+		return g[0].literal
+	}
+	return ""
+}
+
+// Returns the number of bytes of s, from the start, matched by a token
+// other than a star, and whether they match.
+func (token *globToken) matchAt(s string) (int, bool) {
+	switch token.kind {
+	case kGlobLiteral:
+		return len(token.literal), strings.HasPrefix(s, token.literal)
+	case kGlobAny:
+		if len(s) == 0 {
+			return 0, false
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		return size, true
+	case kGlobClass:
+		if len(s) == 0 {
+			return 0, false
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		in := false
+		for _, rr := range token.ranges {
+			if rr.lo <= r && r <= rr.hi {
+				in = true
+				break
+			}
+		}
+		return size, in != token.negated
+	}
+	panic("not reached")
+}
+
+// Every token other than a star matches a fixed string at a given
+// position, so it's enough to remember the last star, and to let it
+// match one more character each time the tokens after it fail.
+func (g glob) match(s string) bool {
+	ti, si := 0, 0
+	starTi, starSi := -1, 0
+	for ti < len(g) || si < len(s) {
+		if ti < len(g) {
+			if g[ti].kind == kGlobStar {
+				starTi, starSi = ti, si
+				ti++
+				continue
+			}
+			if n, ok := g[ti].matchAt(s[si:]); ok {
+				ti++
+				si += n
+				continue
+			}
+		}
+		if starTi < 0 || starSi == len(s) {
+			return false
+		}
+		_, size := utf8.DecodeRuneInString(s[starSi:])
+		starSi += size
+		ti, si = starTi+1, starSi
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package critbit
+
+import (
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestGlobMatch(c *C) {
+	table := []struct {
+		pattern string
+		name    string
+		matches bool
+	}{
+		{"", "", true},
+		{"", "a", false},
+		{"abc", "abc", true},
+		{"abc", "abcd", false},
+		{"*", "", true},
+		{"*", "a/b/c", true},
+		{"a*", "a", true},
+		{"a*c", "abbbc", true},
+		{"a*c", "abbbd", false},
+		{"a*b*c", "aXbYbZc", true},
+		{"a**c", "ac", true},
+		{"?", "é", true},
+		{"??", "é", false},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"[abc]", "b", true},
+		{"[abc]", "d", false},
+		{"[a-c]x", "cx", true},
+		{"[!a-c]x", "cx", false},
+		{"[^a-c]x", "dx", true},
+		{"[]a]", "]", true},
+		{"[a-]", "-", true},
+		{"[\\]]", "]", true},
+		{"\\*", "*", true},
+		{"\\*", "a", false},
+		{"service.*.timeout", "service.db.timeout", true},
+		{"service.*.timeout", "service.db.retries", false},
+		{"service.*.timeout", "service.a.b.timeout", true},
+	}
+	for _, t := range table {
+		g, err := parseGlob(t.pattern)
+		c.Assert(err, IsNil)
+		c.Check(g.match(t.name), Equals, t.matches, Commentf("%q %q", t.pattern, t.name))
+	}
+
+	for _, pattern := range []string{"[", "[a", "[a-", "[b-a]", "a\\"} {
+		_, err := parseGlob(pattern)
+		c.Check(err, NotNil, Commentf("%q", pattern))
+	}
+}
+
+func (s *MySuite) TestMustMatch(c *C) {
+	tree := New[int](0)
+	table := []string{"service.api.retries", "service.api.timeout", "service.db.timeout",
+		"service.timeout", "server.timeout", "services.x.timeout", "zzz"}
+	for i, key := range table {
+		tree.Insert(key, i)
+	}
+
+	var keys []string
+	var values []int
+	for key, value := range tree.MustMatch("service.*.timeout") {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	c.Check(keys, DeepEquals, []string{"service.api.timeout", "service.db.timeout"})
+	c.Check(values, DeepEquals, []int{1, 2})
+
+	for _, t := range []struct {
+		pattern string
+		keys    []string
+	}{
+		{"*timeout", []string{"server.timeout", "service.api.timeout", "service.db.timeout",
+			"service.timeout", "services.x.timeout"}},
+		{"serv?r.*", []string{"server.timeout"}},
+		{"zzz", []string{"zzz"}},
+		{"nope*", nil},
+		{"service.[a-c]*", []string{"service.api.retries", "service.api.timeout"}},
+	} {
+		g, err := CompileGlob(t.pattern)
+		c.Assert(err, IsNil)
+		c.Check(g.String(), Equals, t.pattern)
+		keys = nil
+		for key := range tree.MatchGlob(g) {
+			keys = append(keys, key)
+		}
+		c.Check(keys, DeepEquals, t.keys, Commentf("%q", t.pattern))
+	}
+
+	_, err := CompileGlob("service.[")
+	c.Check(err, ErrorMatches, `Bad pattern "service.\[": unterminated character class`)
+	c.Check(func() { tree.MustMatch("service.[") }, PanicMatches,
+		`Bad pattern "service.\[": unterminated character class`)
+
+	// Empty tree
+	for range New[int](0).MustMatch("*") {
+		c.Fail()
+	}
+}
+
+func (s *MySuite) TestMatchCollation(c *C) {
+	tree := New[int](0, WithCollation(FoldASCII))
+	tree.Insert("Service.API.Timeout", 1)
+	tree.Insert("service.db.timeout", 2)
+	tree.Insert("Server.timeout", 3)
+	for _, t := range []struct {
+		pattern string
+		keys    []string
+	}{
+		{"SERVICE.*.TIMEOUT", []string{"Service.API.Timeout", "service.db.timeout"}},
+		{"[S]ervice.[A-C]*", []string{"Service.API.Timeout"}},
+		{"SERV[!I]?.*", []string{"Server.timeout"}},
+	} {
+		var keys []string
+		for key := range tree.MustMatch(t.pattern) {
+			keys = append(keys, key)
+		}
+		c.Check(keys, DeepEquals, t.keys, Commentf("%q", t.pattern))
+	}
+}
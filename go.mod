@@ -4,6 +4,7 @@ go 1.25.1
 
 require (
 	github.com/pkg/errors v0.9.1
+	golang.org/x/text v0.35.0
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c
 )
 
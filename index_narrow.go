@@ -0,0 +1,20 @@
+//go:build !critbit_wideindex
+
+package critbit
+
+// A nodeIndex identifies an internal node or an external ref within
+// their arrays. By default it is a uint32. Build with the
+// "critbit_wideindex" tag to use a uint64 instead.
+type nodeIndex = uint32
+
+const (
+	kNilNode = 1<<32 - 1
+
+	// Since we use a uint32 to keep track of internal nodes and
+	// external references, we can store up to 2^32 of each. One value,
+	// 0xffffffff is used as a "nil" value. If we have N external refs,
+	// we need N-1 interal nodes to differentiate them. So, the max number
+	// of external refs (strings) is (2^32)-1, and accordingly, the max
+	// number of internal nodes we would use would be (2^32)-2.
+	kMaxStrings = 1<<32 - 1
+)
@@ -0,0 +1,17 @@
+//go:build critbit_wideindex
+
+package critbit
+
+// A nodeIndex identifies an internal node or an external ref within
+// their arrays. The "critbit_wideindex" build tag selects a uint64,
+// which grows internalNode by 8 bytes but lifts the limit on the
+// number of keys well beyond what fits in memory.
+type nodeIndex = uint64
+
+const (
+	kNilNode = 1<<64 - 1
+
+	// As with the uint32 indices, one value is reserved as the "nil"
+	// value, so the max number of external refs is (2^64)-1.
+	kMaxStrings = 1<<64 - 1
+)
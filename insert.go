@@ -11,14 +11,29 @@ import (
 // is full, or if the string is too long to be inserted.
 // If an error is returned, the boolean value returned will be false.
 func (tree *Critbit[T]) Insert(key string, value T) (bool, error) {
+	sortKey := tree.collate(key)
+	return tree.insert(sortKey, bitLen(sortKey), key, value)
+}
+
+// The key is the sort key, keyBits is its length in bits, and original
+// is the key as given to Insert.
+func (tree *Critbit[T]) insert(key string, keyBits int64, original string, value T) (bool, error) {
+	inserted, err := tree.insertRef(key, keyBits, original, value)
+	if inserted && tree.hasAugments() {
+		tree.refreshAugments(key, keyBits)
+	}
+	return inserted, err
+}
+
+func (tree *Critbit[T]) insertRef(key string, keyBits int64, original string, value T) (bool, error) {
 	// Sanity check
-	if len(key) > kMaxStringLength {
-		return false, errors.Errorf("Maximum string length is %d", kMaxStringLength)
+	if uint64(len(key)) > kMaxStringLength {
+		return false, errors.Errorf("Maximum string length is %d", uint64(kMaxStringLength))
 	}
 
 	// Is the tree empty? Insert the first ref
 	if tree.numExternalRefs == 0 {
-		err := tree.insertFirstString(key, value)
+		err := tree.insertFirstString(key, keyBits, original, value)
 		if err != nil {
 			return false, errors.Wrap(err, "Insert() first key")
 		}
@@ -26,10 +41,10 @@ func (tree *Critbit[T]) Insert(key string, value T) (bool, error) {
 	}
 
 	// Find the best external reference
-	bestRefNum := tree.findBestExternalReference(key)
+	bestRefNum := tree.findBestExternalReference(key, keyBits)
 
 	// find critical bit
-	identical, off, bit, ndir := tree.findCriticalBit(bestRefNum, key)
+	identical, off, bit, ndir := tree.findCriticalBit(bestRefNum, key, keyBits)
 
 	// Is it already in the tree?
 	if identical {
@@ -39,7 +54,7 @@ func (tree *Critbit[T]) Insert(key string, value T) (bool, error) {
 	// If there is only one external ref, then there are no internal nodes.
 	// Insert the first node (and a new ref)
 	if tree.numExternalRefs == 1 {
-		err := tree.insertSecondString(key, value, off, bit, ndir)
+		err := tree.insertSecondString(key, keyBits, original, value, off, bit, ndir)
 		if err != nil {
 			return false, errors.Wrap(err, "Insert() second key")
 		}
@@ -48,10 +63,10 @@ func (tree *Critbit[T]) Insert(key string, value T) (bool, error) {
 
 	// Find the node from which to branch
 	branchNodeNum, parentNodeNum, prevDirection, insertAtRoot,
-		finalChildType := tree.findBranchNode(off, bit, key)
+		finalChildType := tree.findBranchNode(off, bit, key, keyBits)
 
 	// Add the new ref
-	newRefNum, err := tree.addExternalRef(key, value)
+	newRefNum, err := tree.addExternalRef(key, keyBits, original, value)
 	if err != nil {
 		return false, errors.Wrap(err, "Insert() adding an external ref")
 	}
@@ -79,8 +94,8 @@ func (tree *Critbit[T]) Insert(key string, value T) (bool, error) {
 }
 
 // Adds the first ref but no node
-func (tree *Critbit[T]) insertFirstString(key string, value T) error {
-	refNum, err := tree.addExternalRef(key, value)
+func (tree *Critbit[T]) insertFirstString(key string, keyBits int64, original string, value T) error {
+	refNum, err := tree.addExternalRef(key, keyBits, original, value)
 	if err != nil {
 		return err
 	}
@@ -90,8 +105,9 @@ func (tree *Critbit[T]) insertFirstString(key string, value T) error {
 
 // Adds the first node, and sets the existing single ref as a child,
 // and adds another ref for the other child.
-func (tree *Critbit[T]) insertSecondString(key string, value T, off uint16, bit byte, ndir byte) error {
-	refNum, err := tree.addExternalRef(key, value)
+func (tree *Critbit[T]) insertSecondString(key string, keyBits int64, original string, value T,
+	off keyOffset, bit byte, ndir byte) error {
+	refNum, err := tree.addExternalRef(key, keyBits, original, value)
 	if err != nil {
 		return err
 	}
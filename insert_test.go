@@ -1,6 +1,8 @@
 package critbit
 
 import (
+	"strings"
+
 	// Bring the symbols in check.v1 into this namespace
 	. "gopkg.in/check.v1"
 )
@@ -133,3 +135,64 @@ func (s *MySuite) TestInsertSecondString(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(keys, DeepEquals, []string{"@@@", "AAA", "CCC"})
 }
+
+// Keys whose length is exactly 64k used to be mishandled, because the
+// offset comparisons were done in uint16, where 65,536 wraps to 0.
+func (s *MySuite) TestInsertLongKeys(c *C) {
+	n := 1 << 16
+	base := strings.Repeat("x", n-1)
+	table := []string{base, base + "a", base + "b"}
+	tree := New[int](0)
+
+	for i, key := range table {
+		ok, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+		c.Check(ok, Equals, true)
+	}
+	for i, key := range table {
+		value, has := tree.Get(key)
+		c.Check(has, Equals, true)
+		c.Check(value, Equals, i)
+	}
+	c.Check(tree.Keys(), DeepEquals, table)
+
+	ok, err := tree.Insert(base+"ab", 3)
+	if uint64(n) == MaxStringLength {
+		c.Check(err, NotNil)
+		c.Check(ok, Equals, false)
+	} else {
+		c.Check(err, IsNil)
+		c.Check(ok, Equals, true)
+	}
+}
+
+func (s *MySuite) TestInsertTrailingNuls(c *C) {
+	table := []string{"a\x00", "", "a", "\x00", "a\x00\x00", "\x00\x00", "a\x00b", "ab", "a\x01"}
+	tree := New[int](0)
+	for i, key := range table {
+		ok, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+		c.Check(ok, Equals, true, Commentf("%q", key))
+	}
+	c.Check(tree.Length(), Equals, len(table))
+	for i, key := range table {
+		value, found := tree.Get(key)
+		c.Check(found, Equals, true, Commentf("%q", key))
+		c.Check(value, Equals, i, Commentf("%q", key))
+	}
+	_, found := tree.Get("\x00\x00\x00")
+	c.Check(found, Equals, false)
+	_, found = tree.Get("ab\x00")
+	c.Check(found, Equals, false)
+
+	// A key sorts before the keys which extend it
+	c.Check(tree.Keys(), DeepEquals, []string{"", "\x00", "\x00\x00", "a", "a\x00",
+		"a\x00\x00", "a\x00b", "a\x01", "ab"})
+
+	c.Check(tree.Delete("a"), Equals, true)
+	value, found := tree.Get("a\x00")
+	c.Check(found, Equals, true)
+	c.Check(value, Equals, 0)
+	_, found = tree.Get("a")
+	c.Check(found, Equals, false)
+}
@@ -0,0 +1,125 @@
+// Package benchdata generates the datasets which the critbit benchmarks
+// and cmd/critbit-bench use. The datasets are generated from a fixed seed,
+// so they are the same on every run, and no files need to be shipped.
+package benchdata
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+)
+
+// A Dataset is a named generator of distinct keys.
+type Dataset struct {
+	Name string
+	Keys func(n int) []string
+}
+
+// Datasets are the datasets, in the order they are reported.
+var Datasets = []Dataset{
+	{"words", Words},
+	{"urls", URLs},
+	{"uuids", UUIDs},
+	{"ints", SequentialInts},
+}
+
+// Find returns the dataset with the name, and whether there is one.
+func Find(name string) (Dataset, bool) {
+	for _, dataset := range Datasets {
+		if dataset.Name == name {
+			return dataset, true
+		}
+	}
+	return Dataset{}, false
+}
+
+var syllables = []string{
+	"a", "al", "an", "ar", "be", "ca", "co", "de", "di", "el", "en", "er",
+	"es", "fa", "ge", "in", "is", "la", "le", "li", "lo", "ma", "me", "mi",
+	"na", "ne", "no", "on", "or", "pa", "pe", "ra", "re", "ri", "ro", "sa",
+	"se", "si", "ta", "te", "ti", "to", "tra", "un", "ur", "va", "ve", "vi",
+}
+
+var suffixes = []string{"", "", "", "s", "ed", "ing", "er", "ly", "ness", "tion"}
+
+// Returns a word-like string; common syllables make the words share
+// prefixes, as natural language words do.
+func word(rng *rand.Rand) string {
+	var w string
+	for n := 1 + rng.Intn(4); n > 0; n-- {
+		w += syllables[rng.Intn(len(syllables))]
+	}
+	return w + suffixes[rng.Intn(len(suffixes))]
+}
+
+// Returns n distinct strings from gen
+func distinct(n int, seed int64, gen func(rng *rand.Rand, i int) string) []string {
+	rng := rand.New(rand.NewSource(seed))
+	seen := make(map[string]bool, n)
+	keys := make([]string, 0, n)
+	for i := 0; len(keys) < n; i++ {
+		key := gen(rng, i)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Words returns n distinct dictionary-like words, in random order. Past
+// the number of short words, they get longer, like compound words.
+func Words(n int) []string {
+	return distinct(n, 1, func(rng *rand.Rand, i int) string {
+		w := word(rng)
+		if i > 4*n/5 {
+			w += word(rng)
+		}
+		return w
+	})
+}
+
+var hosts = []string{
+	"example.com", "www.example.com", "api.example.com", "docs.example.org",
+	"cdn.example.net", "shop.example.co.uk", "blog.example.io", "example.edu",
+}
+
+// URLs returns n distinct URLs, in random order. A few hosts share most
+// of the URLs, so the keys have long common prefixes.
+func URLs(n int) []string {
+	return distinct(n, 2, func(rng *rand.Rand, i int) string {
+		url := "https://" + hosts[rng.Intn(len(hosts))]
+		for depth := 1 + rng.Intn(3); depth > 0; depth-- {
+			url += "/" + word(rng)
+		}
+		if rng.Intn(3) == 0 {
+			url += fmt.Sprintf("?id=%d", rng.Intn(100000))
+		}
+		return url
+	})
+}
+
+// UUIDs returns n distinct version 4 UUIDs, as strings, in random order.
+// Their bits are random, so they share only short prefixes.
+func UUIDs(n int) []string {
+	return distinct(n, 3, func(rng *rand.Rand, i int) string {
+		var b [16]byte
+		rng.Read(b[:])
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	})
+}
+
+// SequentialInts returns the integers from 0 to n-1, encoded big-endian in
+// 8 bytes, so that they sort in numeric order, in increasing order. The
+// keys share all but their last few bytes.
+func SequentialInts(n int) []string {
+	keys := make([]string, n)
+	var b [8]byte
+	for i := range keys {
+		binary.BigEndian.PutUint64(b[:], uint64(i))
+		keys[i] = string(b[:])
+	}
+	return keys
+}
@@ -17,7 +17,7 @@ type KeyValueTuple[T any] struct {
 
 type walkerItem struct {
 	itemType uint8
-	itemID   uint32
+	itemID   nodeIndex
 }
 
 type walkerStack struct {
@@ -57,7 +57,7 @@ func (tree *Critbit[T]) newWalkerStack() *walkerStack {
 	// The maximum size of the stack is the number of nodes we still
 	// need to visit, which is the height of the tree. The max height
 	// of the tree is bounded by the length of the keys, which goes up
-	// to 64k characters. However, we are also bounded by the current number of
+	// to MaxStringLength characters. However, we are also bounded by the current number of
 	// refs in the tree. We can't understand the topology, but we can make a good
 	// guess.  So, we preallocate a good amount which should cover most trees,
 	// and allow for dynamic growth for the corner cases.
@@ -75,14 +75,14 @@ func (tree *Critbit[T]) newWalkerStack() *walkerStack {
 	}
 }
 
-func (tree *Critbit[T]) createWalkerItemFromNodeNum(nodeNum uint32) *walkerItem {
+func (tree *Critbit[T]) createWalkerItemFromNodeNum(nodeNum nodeIndex) *walkerItem {
 	return &walkerItem{
 		itemType: kChildIntNode,
 		itemID:   nodeNum,
 	}
 }
 
-func (tree *Critbit[T]) createWalkerItemFromRefNum(refNum uint32) *walkerItem {
+func (tree *Critbit[T]) createWalkerItemFromRefNum(refNum nodeIndex) *walkerItem {
 	return &walkerItem{
 		itemType: kChildExtRef,
 		itemID:   refNum,
@@ -149,20 +149,27 @@ func (tree *Critbit[T]) GetKeyValueTuples() []*KeyValueTuple[T] {
 
 func (tree *Critbit[T]) _iterateKeyTuples(ctx context.Context, tupleChan chan *KeyValueTuple[T]) {
 	defer close(tupleChan)
-	switch tree.rootItemType() {
+	tree.walkRefs(tree.rootItemType(), tree.rootItem, func(refNum nodeIndex) bool {
+		return tree.sendKeyTuple(ctx, refNum, tupleChan)
+	})
+}
+
+// walkRefs calls fn for each ref under an item, in key order, until fn
+// returns false. It returns false if fn stopped the walk.
+func (tree *Critbit[T]) walkRefs(itemType byte, itemID nodeIndex, fn func(refNum nodeIndex) bool) bool {
+	switch itemType {
 	case kChildNil:
 		// Empty tree?
-		return
+		return true
 
 	case kChildExtRef:
 		// One ref?
-		tree.sendKeyTuple(ctx, tree.rootItem, tupleChan)
-		return
+		return fn(itemID)
 	}
 
 	// Push the first item in the stack
 	stack := tree.newWalkerStack()
-	stack.push(tree.createWalkerItemFromNodeNum(tree.rootItem))
+	stack.push(tree.createWalkerItemFromNodeNum(itemID))
 
 	// Walk the tree
 	for stack.Len() > 0 {
@@ -172,9 +179,9 @@ func (tree *Critbit[T]) _iterateKeyTuples(ctx context.Context, tupleChan chan *K
 
 		// leaf?
 		if walker.itemType == kChildExtRef {
-			keepGoing := tree.sendKeyTuple(ctx, walker.itemID, tupleChan)
+			keepGoing := fn(walker.itemID)
 			if !keepGoing {
-				return
+				return false
 			}
 
 		} else {
@@ -202,13 +209,14 @@ func (tree *Critbit[T]) _iterateKeyTuples(ctx context.Context, tupleChan chan *K
 			}
 		}
 	}
+	return true
 }
 
 // Returns 'keepGoing'
-func (tree *Critbit[T]) sendKeyTuple(ctx context.Context, refNum uint32, tupleChan chan *KeyValueTuple[T]) bool {
+func (tree *Critbit[T]) sendKeyTuple(ctx context.Context, refNum nodeIndex, tupleChan chan *KeyValueTuple[T]) bool {
 	ref := &tree.externalRefs[refNum]
 	kvt := &KeyValueTuple[T]{
-		Key:   ref.key,
+		Key:   tree.refOriginalKey(refNum),
 		Value: ref.value,
 	}
 	select {
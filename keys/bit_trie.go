@@ -0,0 +1,116 @@
+package keys
+
+import (
+	"iter"
+
+	"github.com/gilramir/critbit"
+)
+
+// A BitTrie is a map whose keys are BitStrings, which can end at any
+// bit. A key and its extensions are distinct keys, and a BitTrie can
+// find the keys which are prefixes of a BitString, as in a Huffman code
+// table or a binary routing table. The keys are stored in the tree with
+// their lengths in bits, with the tree's *Bits methods.
+type BitTrie[V any] struct {
+	tree *critbit.Critbit[V]
+}
+
+// NewBitTrie allocates a new BitTrie. The capacityKeys and options
+// arguments are passed to critbit.New; the tree can't index bit keys
+// through a collation, so inserting into a BitTrie made with
+// WithCollation returns an error.
+func NewBitTrie[V any](capacityKeys int, options ...critbit.Option) *BitTrie[V] {
+	return &BitTrie[V]{
+		tree: critbit.New[V](capacityKeys, options...),
+	}
+}
+
+// Tree returns the underlying tree.
+func (t *BitTrie[V]) Tree() *critbit.Critbit[V] {
+	return t.tree
+}
+
+// Length returns the number of keys in the trie.
+func (t *BitTrie[V]) Length() int {
+	return t.tree.Length()
+}
+
+// Insert inserts a key/value pair, like Critbit.Insert.
+func (t *BitTrie[V]) Insert(key BitString, value V) (bool, error) {
+	return t.tree.InsertBits(key.bytes, key.length, value)
+}
+
+// Upsert inserts or updates a key/value pair, like Critbit.Upsert.
+func (t *BitTrie[V]) Upsert(key BitString, value V) error {
+	return t.tree.UpsertBits(key.bytes, key.length, value)
+}
+
+// Update changes the value of an existing key, like Critbit.Update.
+func (t *BitTrie[V]) Update(key BitString, value V) bool {
+	return t.tree.UpdateBits(key.bytes, key.length, value)
+}
+
+// Get finds the key and returns its value, like Critbit.Get.
+func (t *BitTrie[V]) Get(key BitString) (V, bool) {
+	return t.tree.GetBits(key.bytes, key.length)
+}
+
+// Delete removes the key, like Critbit.Delete.
+func (t *BitTrie[V]) Delete(key BitString) bool {
+	return t.tree.DeleteBits(key.bytes, key.length)
+}
+
+// Keys returns all the keys in the trie, in order. A key sorts before
+// its extensions, and those that continue with a 0 before those that
+// continue with a 1.
+func (t *BitTrie[V]) Keys() []BitString {
+	keys := make([]BitString, 0, t.tree.Length())
+	for key := range t.IterateItems() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// IterateItems returns an iterator over the (key, value) pairs, in
+// key order.
+func (t *BitTrie[V]) IterateItems() iter.Seq2[BitString, V] {
+	return fromBitKeys(t.tree.IterateBits())
+}
+
+// LongestPrefix finds the longest key which is a prefix of a BitString,
+// or is the BitString itself, and returns the key and its value.
+func (t *BitTrie[V]) LongestPrefix(b BitString) (BitString, V, bool) {
+	key, value, found := t.tree.LongestPrefixBits(b.bytes, b.length)
+	if !found {
+		return BitString{}, value, false
+	}
+	return fromBitKey(key), value, true
+}
+
+// PrefixesOf returns an iterator over the keys which are prefixes of a
+// BitString, including the BitString itself, from the shortest to the
+// longest.
+func (t *BitTrie[V]) PrefixesOf(b BitString) iter.Seq2[BitString, V] {
+	return fromBitKeys(t.tree.PrefixesOfBits(b.bytes, b.length))
+}
+
+// IteratePrefix returns an iterator over the keys which start with a
+// prefix, including the prefix itself, in key order.
+func (t *BitTrie[V]) IteratePrefix(prefix BitString) iter.Seq2[BitString, V] {
+	return fromBitKeys(t.tree.IteratePrefixBits(prefix.bytes, prefix.length))
+}
+
+// The tree clears the bits past the end of a key, as NewBitString does
+func fromBitKey(key critbit.BitKey) BitString {
+	return BitString{[]byte(key.Bytes), key.Length}
+}
+
+func fromBitKeys[V any](items iter.Seq2[critbit.BitKey, V]) iter.Seq2[BitString, V] {
+	return func(yield func(BitString, V) bool) {
+		for key, value := range items {
+			if !yield(fromBitKey(key), value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package keys
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A BitString is a string of bits, whose length need not be a whole
+// number of bytes. The bits are packed into bytes, most significant
+// bit first.
+type BitString struct {
+	bytes  []byte
+	length int
+}
+
+// NewBitString returns the BitString made of the first bitLength bits
+// of a byte slice. The bytes are copied.
+func NewBitString(b []byte, bitLength int) (BitString, error) {
+	if bitLength < 0 || bitLength > len(b)*8 {
+		return BitString{}, errors.Errorf("keys: can't take %d bits from %d bytes",
+			bitLength, len(b))
+	}
+	bytes := make([]byte, (bitLength+7)/8)
+	copy(bytes, b)
+	// Clear the bits past the end, so that equal BitStrings have
+	// equal bytes
+	if bitLength%8 != 0 {
+		bytes[len(bytes)-1] &= 0xff << (8 - bitLength%8)
+	}
+	return BitString{bytes, bitLength}, nil
+}
+
+// ParseBitString parses a string of '0' and '1' characters.
+func ParseBitString(s string) (BitString, error) {
+	bytes := make([]byte, (len(s)+7)/8)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '0':
+		case '1':
+			bytes[i/8] |= 0x80 >> (i % 8)
+		default:
+			return BitString{}, errors.Errorf("keys: bad bit %q in %q", s[i], s)
+		}
+	}
+	return BitString{bytes, len(s)}, nil
+}
+
+// Len returns the number of bits.
+func (b BitString) Len() int {
+	return b.length
+}
+
+// Bit returns the i'th bit, 0 or 1.
+func (b BitString) Bit(i int) byte {
+	return (b.bytes[i/8] >> (7 - i%8)) & 1
+}
+
+// Bytes returns the bits packed into bytes. The bits past the end of the
+// last byte are zero.
+func (b BitString) Bytes() []byte {
+	return append([]byte(nil), b.bytes...)
+}
+
+// HasPrefix reports whether prefix is a prefix of the BitString. Every
+// BitString is a prefix of itself.
+func (b BitString) HasPrefix(prefix BitString) bool {
+	if prefix.length > b.length {
+		return false
+	}
+	for i := 0; i < prefix.length; i++ {
+		if b.Bit(i) != prefix.Bit(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the bits as '0' and '1' characters.
+func (b BitString) String() string {
+	var sb strings.Builder
+	sb.Grow(b.length)
+	for i := 0; i < b.length; i++ {
+		sb.WriteByte('0' + b.Bit(i))
+	}
+	return sb.String()
+}
@@ -0,0 +1,108 @@
+package keys
+
+import (
+	"github.com/gilramir/critbit"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func mustParseBits(s string) BitString {
+	b, err := ParseBitString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (s *MySuite) TestBitString(c *C) {
+	b, err := NewBitString([]byte{0xa5, 0xff}, 11)
+	c.Assert(err, IsNil)
+	c.Check(b.Len(), Equals, 11)
+	c.Check(b.String(), Equals, "10100101111")
+	c.Check(b.Bytes(), DeepEquals, []byte{0xa5, 0xe0})
+	c.Check(b.Bit(0), Equals, byte(1))
+	c.Check(b.Bit(1), Equals, byte(0))
+
+	// The bits past the end don't matter
+	other, err := NewBitString([]byte{0xa5, 0xe0}, 11)
+	c.Assert(err, IsNil)
+	c.Check(other, DeepEquals, b)
+
+	c.Check(b.HasPrefix(mustParseBits("1010")), Equals, true)
+	c.Check(b.HasPrefix(mustParseBits("")), Equals, true)
+	c.Check(b.HasPrefix(b), Equals, true)
+	c.Check(b.HasPrefix(mustParseBits("1011")), Equals, false)
+	c.Check(mustParseBits("1010").HasPrefix(b), Equals, false)
+
+	_, err = NewBitString([]byte{0xa5}, 9)
+	c.Check(err, NotNil)
+	_, err = ParseBitString("0120")
+	c.Check(err, NotNil)
+}
+
+func (s *MySuite) TestBitStringOrder(c *C) {
+	// A key sorts before its extensions, so the zero-bit extension
+	// of a key doesn't collide with the key, as a zero byte would.
+	m := NewBitTrie[int](0)
+	for i, bits := range []string{"1", "", "10", "0", "100", "11", "01", "1000"} {
+		ok, err := m.Insert(mustParseBits(bits), i)
+		c.Assert(err, IsNil)
+		c.Check(ok, Equals, true, Commentf("%s", bits))
+	}
+	var obtained []string
+	for _, key := range m.Keys() {
+		obtained = append(obtained, key.String())
+	}
+	c.Check(obtained, DeepEquals, []string{"", "0", "01", "1", "10", "100", "1000", "11"})
+}
+
+func (s *MySuite) TestBitTrie(c *C) {
+	// A Huffman code table
+	trie := NewBitTrie[string](0)
+	for bits, symbol := range map[string]string{"0": "a", "10": "b", "110": "c", "111": "d"} {
+		ok, err := trie.Insert(mustParseBits(bits), symbol)
+		c.Assert(err, IsNil)
+		c.Check(ok, Equals, true)
+	}
+
+	// Decode a message by repeatedly taking the longest prefix
+	message := mustParseBits("1100111100")
+	var decoded string
+	for message.Len() > 0 {
+		code, symbol, found := trie.LongestPrefix(message)
+		c.Assert(found, Equals, true)
+		decoded += symbol
+		message = mustParseBits(message.String()[code.Len():])
+	}
+	c.Check(decoded, Equals, "cadba")
+
+	_, _, found := trie.LongestPrefix(mustParseBits("11"))
+	c.Check(found, Equals, false)
+
+	// Prefixes and extensions are distinct keys
+	trie.Insert(mustParseBits("1"), "prefix")
+	var prefixes []string
+	for key, value := range trie.PrefixesOf(mustParseBits("1101")) {
+		prefixes = append(prefixes, key.String()+"="+value)
+	}
+	c.Check(prefixes, DeepEquals, []string{"1=prefix", "110=c"})
+
+	var extensions []string
+	for key := range trie.IteratePrefix(mustParseBits("1")) {
+		extensions = append(extensions, key.String())
+	}
+	c.Check(extensions, DeepEquals, []string{"1", "10", "110", "111"})
+
+	c.Check(trie.Delete(mustParseBits("1")), Equals, true)
+	value, found := trie.Get(mustParseBits("10"))
+	c.Check(found, Equals, true)
+	c.Check(value, Equals, "b")
+
+	// The tree stores the keys packed, with their lengths in bits
+	c.Check(trie.Tree().Keys(), DeepEquals, []string{"\x00", "\x80", "\xc0", "\xe0"})
+	c.Check(trie.Tree().Validate(), IsNil)
+
+	_, err := NewBitTrie[string](0, critbit.WithCollation(critbit.FoldASCII)).Insert(mustParseBits("1"), "a")
+	c.Check(err, NotNil)
+}
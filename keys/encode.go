@@ -0,0 +1,146 @@
+package keys
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+const (
+	kSignBit = 1 << 63
+
+	kInt64Length   = 8
+	kUint64Length  = 8
+	kFloat64Length = 8
+	kTimeLength    = 12
+	kBoolLength    = 1
+)
+
+func appendUint64(dst []byte, v uint64) []byte {
+	return binary.BigEndian.AppendUint64(dst, v)
+}
+
+// Flipping the sign bit puts negative numbers before positive ones.
+func appendInt64(dst []byte, v int64) []byte {
+	return binary.BigEndian.AppendUint64(dst, uint64(v)^kSignBit)
+}
+
+// Positive floats sort correctly once their sign bit is set. Negative
+// floats need all of their bits flipped, so that larger magnitudes
+// sort first.
+func appendFloat64(dst []byte, v float64) []byte {
+	bits := math.Float64bits(v)
+	if bits&kSignBit != 0 {
+		bits = ^bits
+	} else {
+		bits |= kSignBit
+	}
+	return binary.BigEndian.AppendUint64(dst, bits)
+}
+
+// A time is its seconds since the Unix epoch, as an int64, followed by
+// its nanoseconds within that second.
+func appendTime(dst []byte, t time.Time) []byte {
+	dst = appendInt64(dst, t.Unix())
+	return binary.BigEndian.AppendUint32(dst, uint32(t.Nanosecond()))
+}
+
+func appendBool(dst []byte, v bool) []byte {
+	if v {
+		return append(dst, 1)
+	}
+	return append(dst, 0)
+}
+
+func decodeUint64(b string) uint64 {
+	return binary.BigEndian.Uint64([]byte(b[:kUint64Length]))
+}
+
+func decodeInt64(b string) int64 {
+	return int64(decodeUint64(b) ^ kSignBit)
+}
+
+func decodeFloat64(b string) float64 {
+	bits := decodeUint64(b)
+	if bits&kSignBit != 0 {
+		bits &^= kSignBit
+	} else {
+		bits = ^bits
+	}
+	return math.Float64frombits(bits)
+}
+
+func decodeTime(b string) time.Time {
+	nsec := binary.BigEndian.Uint32([]byte(b[kInt64Length:kTimeLength]))
+	return time.Unix(decodeInt64(b), int64(nsec)).UTC()
+}
+
+// EncodeInt64 returns the 8-byte encoding of an int64.
+func EncodeInt64(v int64) string {
+	return string(appendInt64(make([]byte, 0, kInt64Length), v))
+}
+
+// DecodeInt64 decodes the output of EncodeInt64.
+func DecodeInt64(encoded string) (int64, error) {
+	if err := checkLength("an int64", encoded, kInt64Length); err != nil {
+		return 0, err
+	}
+	return decodeInt64(encoded), nil
+}
+
+// EncodeUint64 returns the 8-byte, big-endian encoding of a uint64.
+func EncodeUint64(v uint64) string {
+	return string(appendUint64(make([]byte, 0, kUint64Length), v))
+}
+
+// DecodeUint64 decodes the output of EncodeUint64.
+func DecodeUint64(encoded string) (uint64, error) {
+	if err := checkLength("a uint64", encoded, kUint64Length); err != nil {
+		return 0, err
+	}
+	return decodeUint64(encoded), nil
+}
+
+// EncodeFloat64 returns the 8-byte encoding of a float64. Negative zero
+// sorts just before positive zero. NaNs with the sign bit set sort before
+// negative infinity, and the others sort after positive infinity.
+func EncodeFloat64(v float64) string {
+	return string(appendFloat64(make([]byte, 0, kFloat64Length), v))
+}
+
+// DecodeFloat64 decodes the output of EncodeFloat64.
+func DecodeFloat64(encoded string) (float64, error) {
+	if err := checkLength("a float64", encoded, kFloat64Length); err != nil {
+		return 0, err
+	}
+	return decodeFloat64(encoded), nil
+}
+
+// EncodeTime returns the 12-byte encoding of a time.Time. The encoding
+// keeps the instant in time, but not the location or the monotonic
+// clock reading.
+func EncodeTime(t time.Time) string {
+	return string(appendTime(make([]byte, 0, kTimeLength), t))
+}
+
+// DecodeTime decodes the output of EncodeTime. The time is in UTC.
+func DecodeTime(encoded string) (time.Time, error) {
+	if err := checkLength("a time", encoded, kTimeLength); err != nil {
+		return time.Time{}, err
+	}
+	return decodeTime(encoded), nil
+}
+
+// EncodeBool returns the 1-byte encoding of a bool; false sorts
+// before true.
+func EncodeBool(v bool) string {
+	return string(appendBool(make([]byte, 0, kBoolLength), v))
+}
+
+// DecodeBool decodes the output of EncodeBool.
+func DecodeBool(encoded string) (bool, error) {
+	if err := checkLength("a bool", encoded, kBoolLength); err != nil {
+		return false, err
+	}
+	return encoded[0] != 0, nil
+}
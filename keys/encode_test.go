@@ -0,0 +1,95 @@
+package keys
+
+import (
+	"math"
+	"time"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+// Checks that the encodings of the values, which are in ascending
+// order, are also in ascending order.
+func checkAscending(c *C, encoded []string) {
+	for i := 1; i < len(encoded); i++ {
+		c.Check(encoded[i-1] < encoded[i], Equals, true,
+			Commentf("element %d: %q >= %q", i, encoded[i-1], encoded[i]))
+	}
+}
+
+func (s *MySuite) TestInt64(c *C) {
+	values := []int64{math.MinInt64, -1 << 40, -256, -1, 0, 1, 255, 256, 1 << 40, math.MaxInt64}
+	var encoded []string
+	for _, v := range values {
+		e := EncodeInt64(v)
+		encoded = append(encoded, e)
+		decoded, err := DecodeInt64(e)
+		c.Assert(err, IsNil)
+		c.Check(decoded, Equals, v)
+	}
+	checkAscending(c, encoded)
+
+	_, err := DecodeInt64("abc")
+	c.Check(err, ErrorMatches, "keys: an int64 key is 8 bytes, not 3")
+}
+
+func (s *MySuite) TestUint64(c *C) {
+	values := []uint64{0, 1, 255, 256, 1 << 40, math.MaxUint64}
+	var encoded []string
+	for _, v := range values {
+		e := EncodeUint64(v)
+		encoded = append(encoded, e)
+		decoded, err := DecodeUint64(e)
+		c.Assert(err, IsNil)
+		c.Check(decoded, Equals, v)
+	}
+	checkAscending(c, encoded)
+}
+
+func (s *MySuite) TestFloat64(c *C) {
+	values := []float64{math.Inf(-1), -math.MaxFloat64, -1.5, -1, -math.SmallestNonzeroFloat64,
+		math.Copysign(0, -1), 0, math.SmallestNonzeroFloat64, 1, 1.5, math.MaxFloat64, math.Inf(1)}
+	var encoded []string
+	for _, v := range values {
+		e := EncodeFloat64(v)
+		encoded = append(encoded, e)
+		decoded, err := DecodeFloat64(e)
+		c.Assert(err, IsNil)
+		c.Check(math.Float64bits(decoded), Equals, math.Float64bits(v))
+	}
+	checkAscending(c, encoded)
+
+	decoded, err := DecodeFloat64(EncodeFloat64(math.NaN()))
+	c.Assert(err, IsNil)
+	c.Check(math.IsNaN(decoded), Equals, true)
+}
+
+func (s *MySuite) TestTime(c *C) {
+	values := []time.Time{
+		time.Date(1066, 10, 14, 9, 0, 0, 0, time.UTC),
+		time.Date(1969, 12, 31, 23, 59, 59, 999999999, time.UTC),
+		time.Unix(0, 0),
+		time.Unix(0, 1),
+		time.Date(2024, 2, 29, 12, 0, 0, 0, time.FixedZone("east", 3600)),
+		time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+	}
+	var encoded []string
+	for _, v := range values {
+		e := EncodeTime(v)
+		encoded = append(encoded, e)
+		decoded, err := DecodeTime(e)
+		c.Assert(err, IsNil)
+		c.Check(decoded.Equal(v), Equals, true)
+		c.Check(decoded.Location(), Equals, time.UTC)
+	}
+	checkAscending(c, encoded)
+}
+
+func (s *MySuite) TestBool(c *C) {
+	checkAscending(c, []string{EncodeBool(false), EncodeBool(true)})
+	for _, v := range []bool{false, true} {
+		decoded, err := DecodeBool(EncodeBool(v))
+		c.Assert(err, IsNil)
+		c.Check(decoded, Equals, v)
+	}
+}
@@ -0,0 +1,58 @@
+// Package keys provides order-preserving encodings of non-string types,
+// so that they can be used as the keys of a critbit tree. The encoded
+// keys sort, byte by byte, in the same order as the values they encode,
+// so iterating over a tree returns them in their natural order.
+//
+// An OrderedMap wraps a critbit tree, and uses a Codec to encode its
+// typed keys, so callers don't need to encode and decode keys by hand.
+// A BitTrie is a map of BitStrings, keys which can end at any bit, with
+// lookups of the keys which are prefixes of a BitString.
+package keys
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// A Codec converts keys of type K to and from their order-preserving
+// string encoding.
+type Codec[K any] struct {
+	Encode func(K) (string, error)
+	Decode func(string) (K, error)
+}
+
+var (
+	// Int64Codec encodes int64 keys with EncodeInt64.
+	Int64Codec = Codec[int64]{noError(EncodeInt64), DecodeInt64}
+
+	// Uint64Codec encodes uint64 keys with EncodeUint64.
+	Uint64Codec = Codec[uint64]{noError(EncodeUint64), DecodeUint64}
+
+	// Float64Codec encodes float64 keys with EncodeFloat64.
+	Float64Codec = Codec[float64]{noError(EncodeFloat64), DecodeFloat64}
+
+	// TimeCodec encodes time.Time keys with EncodeTime.
+	TimeCodec = Codec[time.Time]{noError(EncodeTime), DecodeTime}
+
+	// BoolCodec encodes bool keys with EncodeBool.
+	BoolCodec = Codec[bool]{noError(EncodeBool), DecodeBool}
+
+	// TupleCodec encodes Tuple keys with EncodeTuple.
+	TupleCodec = Codec[Tuple]{EncodeTuple, DecodeTuple}
+)
+
+func noError[K any](encode func(K) string) func(K) (string, error) {
+	return func(key K) (string, error) {
+		return encode(key), nil
+	}
+}
+
+// The name has its article, like "an int64"
+func checkLength(name string, encoded string, length int) error {
+	if len(encoded) != length {
+		return errors.Errorf("keys: %s key is %d bytes, not %d",
+			name, length, len(encoded))
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package keys
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/gilramir/critbit"
+)
+
+// An OrderedMap is a critbit tree whose keys are of type K. The keys
+// are encoded with a Codec, so they are iterated over in their natural
+// order.
+type OrderedMap[K any, V any] struct {
+	codec Codec[K]
+	tree  *critbit.Critbit[V]
+}
+
+// NewOrderedMap allocates a new OrderedMap. The capacityKeys and options
+// arguments are passed to critbit.New.
+func NewOrderedMap[K any, V any](codec Codec[K], capacityKeys int,
+	options ...critbit.Option) *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{
+		codec: codec,
+		tree:  critbit.New[V](capacityKeys, options...),
+	}
+}
+
+// Tree returns the underlying tree, whose keys are the encoded keys.
+func (m *OrderedMap[K, V]) Tree() *critbit.Critbit[V] {
+	return m.tree
+}
+
+// Length returns the number of keys in the map.
+func (m *OrderedMap[K, V]) Length() int {
+	return m.tree.Length()
+}
+
+// Insert inserts a key/value pair, like Critbit.Insert. An error is also
+// returned if the key can't be encoded.
+func (m *OrderedMap[K, V]) Insert(key K, value V) (bool, error) {
+	encoded, err := m.codec.Encode(key)
+	if err != nil {
+		return false, err
+	}
+	return m.tree.Insert(encoded, value)
+}
+
+// Upsert inserts or updates a key/value pair, like Critbit.Upsert.
+// An error is also returned if the key can't be encoded.
+func (m *OrderedMap[K, V]) Upsert(key K, value V) error {
+	encoded, err := m.codec.Encode(key)
+	if err != nil {
+		return err
+	}
+	return m.tree.Upsert(encoded, value)
+}
+
+// Update changes the value of an existing key, like Critbit.Update.
+// A key that can't be encoded is never in the map.
+func (m *OrderedMap[K, V]) Update(key K, value V) bool {
+	encoded, err := m.codec.Encode(key)
+	if err != nil {
+		return false
+	}
+	return m.tree.Update(encoded, value)
+}
+
+// Get finds the key and returns its value, like Critbit.Get.
+// A key that can't be encoded is never in the map.
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	encoded, err := m.codec.Encode(key)
+	if err != nil {
+		var nilVal V
+		return nilVal, false
+	}
+	return m.tree.Get(encoded)
+}
+
+// Delete removes the key, like Critbit.Delete.
+// A key that can't be encoded is never in the map.
+func (m *OrderedMap[K, V]) Delete(key K) bool {
+	encoded, err := m.codec.Encode(key)
+	if err != nil {
+		return false
+	}
+	return m.tree.Delete(encoded)
+}
+
+// Keys returns all the keys in the map, in order.
+func (m *OrderedMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.tree.Length())
+	for key := range m.IterateItems() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// IterateItems returns an iterator over the (key, value) pairs, in
+// key order.
+func (m *OrderedMap[K, V]) IterateItems() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for encoded, value := range m.tree.IterateItems() {
+			if !yield(m.decode(encoded), value) {
+				return
+			}
+		}
+	}
+}
+
+// Every key in the tree was encoded by the codec, so failing to decode
+// one is a bug.
+func (m *OrderedMap[K, V]) decode(encoded string) K {
+	key, err := m.codec.Decode(encoded)
+	if err != nil {
+		panic(fmt.Sprintf("Can't decode stored key %q: %v", encoded, err))
+	}
+	return key
+}
@@ -0,0 +1,69 @@
+package keys
+
+import (
+	"github.com/gilramir/critbit"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestOrderedMapNegativeNumbers(c *C) {
+	m := NewOrderedMap[int64, string](Int64Codec, 0)
+	for _, v := range []int64{10, -3, 0, -100, 7, 1 << 40, -1} {
+		ok, err := m.Insert(v, "")
+		c.Assert(err, IsNil)
+		c.Check(ok, Equals, true)
+	}
+	c.Check(m.Keys(), DeepEquals, []int64{-100, -3, -1, 0, 7, 10, 1 << 40})
+	c.Check(m.Length(), Equals, 7)
+}
+
+func (s *MySuite) TestOrderedMap(c *C) {
+	m := NewOrderedMap[float64, int](Float64Codec, 0, critbit.WithKeyArena(0))
+
+	ok, err := m.Insert(-1.5, 1)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, true)
+	ok, err = m.Insert(-1.5, 2)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, false)
+	c.Check(m.Upsert(2.5, 3), IsNil)
+	c.Check(m.Update(2.5, 4), Equals, true)
+	c.Check(m.Update(3.5, 4), Equals, false)
+
+	value, has := m.Get(2.5)
+	c.Check(has, Equals, true)
+	c.Check(value, Equals, 4)
+	_, has = m.Get(0)
+	c.Check(has, Equals, false)
+
+	var keys []float64
+	var values []int
+	for key, value := range m.IterateItems() {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	c.Check(keys, DeepEquals, []float64{-1.5, 2.5})
+	c.Check(values, DeepEquals, []int{1, 4})
+
+	c.Check(m.Delete(-1.5), Equals, true)
+	c.Check(m.Delete(-1.5), Equals, false)
+	c.Check(m.Tree().Length(), Equals, 1)
+}
+
+func (s *MySuite) TestOrderedMapTuples(c *C) {
+	m := NewOrderedMap[Tuple, int](TupleCodec, 0)
+	_, err := m.Insert(Tuple{int64(2), true}, 1)
+	c.Assert(err, IsNil)
+	_, err = m.Insert(Tuple{int64(-2), false}, 2)
+	c.Assert(err, IsNil)
+	_, err = m.Insert(Tuple{int64(-2)}, 3)
+	c.Assert(err, IsNil)
+
+	_, err = m.Insert(Tuple{"bad"}, 4)
+	c.Check(err, NotNil)
+	_, has := m.Get(Tuple{"bad"})
+	c.Check(has, Equals, false)
+
+	c.Check(m.Keys(), DeepEquals, []Tuple{{int64(-2)}, {int64(-2), false}, {int64(2), true}})
+}
@@ -0,0 +1,93 @@
+package keys
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// A Tuple is a composite key. Its elements must be bool, int64, uint64,
+// float64 or time.Time values. Tuples sort element by element; a tuple
+// sorts before any longer tuple that it is a prefix of.
+type Tuple []any
+
+// Each element is encoded as a tag byte followed by the element's
+// fixed-length encoding. As every encoding has a fixed length, no
+// escaping is needed between elements. Elements of different types,
+// at the same position, sort by their tags. No tag is zero, so an
+// encoded tuple never ends where a longer one continues with a zero.
+const (
+	kTagBool    = 0x01
+	kTagInt64   = 0x02
+	kTagUint64  = 0x03
+	kTagFloat64 = 0x04
+	kTagTime    = 0x05
+)
+
+// EncodeTuple returns the encoding of a tuple. An error is returned if
+// an element has an unsupported type.
+func EncodeTuple(tuple Tuple) (string, error) {
+	encoded := make([]byte, 0, len(tuple)*(1+kFloat64Length))
+	for i, element := range tuple {
+		switch v := element.(type) {
+		case bool:
+			encoded = appendBool(append(encoded, kTagBool), v)
+		case int64:
+			encoded = appendInt64(append(encoded, kTagInt64), v)
+		case uint64:
+			encoded = appendUint64(append(encoded, kTagUint64), v)
+		case float64:
+			encoded = appendFloat64(append(encoded, kTagFloat64), v)
+		case time.Time:
+			encoded = appendTime(append(encoded, kTagTime), v)
+		default:
+			return "", errors.Errorf("keys: tuple element %d has unsupported type %T",
+				i, element)
+		}
+	}
+	return string(encoded), nil
+}
+
+// DecodeTuple decodes the output of EncodeTuple.
+func DecodeTuple(encoded string) (Tuple, error) {
+	var tuple Tuple
+	for len(encoded) > 0 {
+		tag := encoded[0]
+		encoded = encoded[1:]
+
+		var length int
+		switch tag {
+		case kTagBool:
+			length = kBoolLength
+		case kTagInt64:
+			length = kInt64Length
+		case kTagUint64:
+			length = kUint64Length
+		case kTagFloat64:
+			length = kFloat64Length
+		case kTagTime:
+			length = kTimeLength
+		default:
+			return nil, errors.Errorf("keys: tuple element %d has unknown tag 0x%02x",
+				len(tuple), tag)
+		}
+		if len(encoded) < length {
+			return nil, errors.Errorf("keys: tuple element %d is truncated", len(tuple))
+		}
+
+		switch tag {
+		case kTagBool:
+			tuple = append(tuple, encoded[0] != 0)
+		case kTagInt64:
+			tuple = append(tuple, decodeInt64(encoded))
+		case kTagUint64:
+			tuple = append(tuple, decodeUint64(encoded))
+		case kTagFloat64:
+			tuple = append(tuple, decodeFloat64(encoded))
+		case kTagTime:
+			tuple = append(tuple, decodeTime(encoded))
+		}
+		encoded = encoded[length:]
+	}
+	return tuple, nil
+}
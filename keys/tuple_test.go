@@ -0,0 +1,57 @@
+package keys
+
+import (
+	"time"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestTuple(c *C) {
+	when := time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)
+	tuples := []Tuple{
+		{},
+		{false},
+		{true},
+		{true, int64(-5)},
+		{true, int64(-5), uint64(0)},
+		{true, int64(-5), uint64(7)},
+		{true, int64(3)},
+		{int64(-1)},
+		{int64(-1), when},
+		{int64(-1), when.Add(time.Nanosecond)},
+		{int64(0)},
+		{uint64(0)},
+		{-2.5, false},
+		{2.5},
+		{when},
+	}
+	var encoded []string
+	for _, tuple := range tuples {
+		e, err := EncodeTuple(tuple)
+		c.Assert(err, IsNil)
+		encoded = append(encoded, e)
+		decoded, err := DecodeTuple(e)
+		c.Assert(err, IsNil)
+		c.Check(decoded, HasLen, len(tuple))
+		for i := range tuple {
+			if t, ok := tuple[i].(time.Time); ok {
+				c.Check(decoded[i].(time.Time).Equal(t), Equals, true)
+			} else {
+				c.Check(decoded[i], Equals, tuple[i])
+			}
+		}
+	}
+	checkAscending(c, encoded)
+}
+
+func (s *MySuite) TestTupleErrors(c *C) {
+	_, err := EncodeTuple(Tuple{int64(1), "string"})
+	c.Check(err, ErrorMatches, "keys: tuple element 1 has unsupported type string")
+
+	_, err = DecodeTuple("\x09")
+	c.Check(err, ErrorMatches, "keys: tuple element 0 has unknown tag 0x09")
+
+	_, err = DecodeTuple("\x01\x01\x02\x00")
+	c.Check(err, ErrorMatches, "keys: tuple element 1 is truncated")
+}
@@ -6,79 +6,89 @@ func (s LOUDS) ToBytes() []byte {
 	return []byte(s)
 }
 
-// LoudsSlice returns a slice of bytes, all of which are either 1 or 0,
+// BitVector packs a LOUDS into a BitVector, 8 times smaller, which can be
+// navigated.
+func (s LOUDS) BitVector() *BitVector {
+	bv := newBitVector(len(s))
+	for _, b := range s {
+		bv.append(b != 0)
+	}
+	bv.buildIndex()
+	return bv
+}
+
+// Louds returns a slice of bytes, all of which are either 1 or 0,
 // which represent the tree structure in the LOUDS (level-order unary
 // degree separation) representation, a succinct representation of the tree.
 // Given N nodes (internal nodes + external refs), 2N+1 bytes will
 // be returned in the slice. See
 // https://memoria-framework.dev/docs/data-zoo/louds-tree/
-// for an introduction to LOUDS.
+// for an introduction to LOUDS. LoudsBits returns the same bits, packed.
 func (tree *Critbit[T]) Louds() LOUDS {
 	n := tree.numInternalNodes + tree.numExternalRefs
-	if n == 0 {
-		return LOUDS([]byte{0})
-	}
-	bits := 2*n + 1
-	answer := make([]byte, 2, bits)
-	// Fake super-root
-	answer[0] = 1
-	answer[1] = 0
-
-	byteChan := make(chan byte)
-
-	go tree._loudSlice(byteChan)
-
-	for b := range byteChan {
-		answer = append(answer, b)
-	}
+	answer := make([]byte, 0, 2*n+1)
+	tree.walkLouds(func(bit bool) {
+		if bit {
+			answer = append(answer, 1)
+		} else {
+			answer = append(answer, 0)
+		}
+	})
 	return LOUDS(answer)
 }
 
-type itemTuple struct {
-	itemID   uint32
-	itemType byte
+// LoudsBits returns the tree structure in the LOUDS representation, like
+// Louds, but packed into a BitVector, which can navigate the tree with
+// FirstChild, NextSibling and Parent. In the BitVector, the internal
+// nodes and external refs are numbered from 0, in level order; node 0 is
+// the root.
+func (tree *Critbit[T]) LoudsBits() *BitVector {
+	n := tree.numInternalNodes + tree.numExternalRefs
+	bv := newBitVector(2*n + 1)
+	tree.walkLouds(bv.append)
+	bv.buildIndex()
+	return bv
 }
 
-// Walk breadth-first
-func (tree *Critbit[T]) _loudSlice(byteChan chan byte) {
-	defer close(byteChan)
-	rootLayer := make([]itemTuple, 1)
-	rootLayer[0].itemID = tree.rootItem
-	rootLayer[0].itemType = tree.rootItemType()
-	tree._loudSliceLayer(byteChan, rootLayer)
+// Emits the LOUDS bits of the tree, starting with the fake super-root
+func (tree *Critbit[T]) walkLouds(emit func(bit bool)) {
+	if tree.numExternalRefs == 0 {
+		emit(false)
+		return
+	}
+	// Fake super-root
+	emit(true)
+	emit(false)
+	tree.walkLevelOrder(func(itemType byte, _ nodeIndex) {
+		// By definition, an internal node has 2 children.
+		if itemType == kChildIntNode {
+			emit(true)
+			emit(true)
+		}
+		emit(false)
+	})
 }
 
-func (tree *Critbit[T]) _loudSliceLayer(byteChan chan byte, layer []itemTuple) {
-	nextLayer := make([]itemTuple, 0)
-
-	for _, item := range layer {
-		children := tree._loudSliceNode(byteChan, item)
-		nextLayer = append(nextLayer, children...)
+// Calls visit for each item in the tree, in level order: breadth-first,
+// left before right.
+func (tree *Critbit[T]) walkLevelOrder(visit func(itemType byte, itemID nodeIndex)) {
+	if tree.numExternalRefs == 0 {
+		return
 	}
-	if len(nextLayer) > 0 {
-		tree._loudSliceLayer(byteChan, nextLayer)
+	type levelItem struct {
+		itemType byte
+		itemID   nodeIndex
 	}
-}
-
-func (tree *Critbit[T]) _loudSliceNode(byteChan chan byte, item itemTuple) []itemTuple {
-	switch item.itemType {
-	case kChildNil:
-		panic("not reached")
-	case kChildExtRef:
-		byteChan <- 0
-		return nil
-	case kChildIntNode:
-		// By definition, and internal node has 2 children.
-		byteChan <- 1
-		byteChan <- 1
-		byteChan <- 0
-		node := tree.internalNodes[item.itemID]
-		children := make([]itemTuple, 2)
-		children[0].itemID = node.child[0]
-		children[0].itemType = node.getChildType(0)
-		children[1].itemID = node.child[1]
-		children[1].itemType = node.getChildType(1)
-		return children
+	queue := make([]levelItem, 1, tree.numInternalNodes+tree.numExternalRefs)
+	queue[0] = levelItem{tree.rootItemType(), tree.rootItem}
+	for i := 0; i < len(queue); i++ {
+		item := queue[i]
+		visit(item.itemType, item.itemID)
+		if item.itemType == kChildIntNode {
+			node := &tree.internalNodes[item.itemID]
+			queue = append(queue,
+				levelItem{node.getChildType(kDirectionLeft), node.child[kDirectionLeft]},
+				levelItem{node.getChildType(kDirectionRight), node.child[kDirectionRight]})
+		}
 	}
-	return nil
 }
@@ -0,0 +1,88 @@
+package critbit
+
+// Nearest returns the k keys which share the longest common prefix with
+// the query, and their values. Keys with longer common prefixes come
+// first; keys with common prefixes of the same length are in sorted
+// order.
+//
+// The search finds the key closest to the query, as Get would, and its
+// critical bit. Every key in the subtree hanging off the query's path at
+// a node shares min(node offset, critical offset) bytes with the query,
+// so the search backs up the path from the divergence point, walking
+// the sibling subtrees beside it.
+func (tree *Critbit[T]) Nearest(query string, k int) []*KeyValueTuple[T] {
+	if k <= 0 || tree.numExternalRefs == 0 {
+		return nil
+	}
+	query = tree.collate(query)
+
+	// Descend as far as the query goes
+	var pathNodes []nodeIndex
+	var pathDirections []byte
+	itemType := tree.rootItemType()
+	itemID := tree.rootItem
+	for itemType == kChildIntNode {
+		node := &tree.internalNodes[itemID]
+		direction := node.direction(query, bitLen(query))
+		pathNodes = append(pathNodes, itemID)
+		pathDirections = append(pathDirections, direction)
+		itemType = node.getChildType(direction)
+		itemID = node.child[direction]
+	}
+	leafRefNum := itemID
+	common := len(query)
+	if identical, off, _, _ := tree.findCriticalBit(leafRefNum, query, bitLen(query)); !identical {
+		common = int(off)
+	}
+	commonPrefixLength := func(i int) int {
+		return min(int(tree.internalNodes[pathNodes[i]].offset), common)
+	}
+
+	results := make([]*KeyValueTuple[T], 0, min(k, tree.numExternalRefs))
+	collect := func(refNum nodeIndex) bool {
+		results = append(results, &KeyValueTuple[T]{
+			Key:   tree.refOriginalKey(refNum),
+			Value: tree.externalRefs[refNum].value,
+		})
+		return len(results) < k
+	}
+	walkSibling := func(i int) bool {
+		node := &tree.internalNodes[pathNodes[i]]
+		sibling := 1 - pathDirections[i]
+		return tree.walkRefs(node.getChildType(sibling), node.child[sibling], collect)
+	}
+
+	// Each group of path nodes with the same common prefix length holds
+	// the keys with that length. Within a group, the siblings on the left
+	// of the path come before the part of the tree below the group, and
+	// the siblings on the right after it.
+	for end, first := len(pathNodes), true; ; first = false {
+		length := common
+		if !first {
+			length = commonPrefixLength(end - 1)
+		}
+		begin := end
+		for begin > 0 && commonPrefixLength(begin-1) == length {
+			begin--
+		}
+
+		for i := begin; i < end; i++ {
+			if pathDirections[i] == kDirectionRight && !walkSibling(i) {
+				return results
+			}
+		}
+		if first && !collect(leafRefNum) {
+			return results
+		}
+		for i := end - 1; i >= begin; i-- {
+			if pathDirections[i] == kDirectionLeft && !walkSibling(i) {
+				return results
+			}
+		}
+
+		if begin == 0 {
+			return results
+		}
+		end = begin
+	}
+}
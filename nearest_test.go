@@ -0,0 +1,76 @@
+package critbit
+
+import (
+	"math/rand"
+	"sort"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func commonPrefixLength(a string, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// Computes the nearest keys by sorting every key
+func expectedNearest(tree *Critbit[int], query string, k int) []string {
+	keys := tree.Keys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		return commonPrefixLength(keys[i], query) > commonPrefixLength(keys[j], query)
+	})
+	if len(keys) > k {
+		keys = keys[:k]
+	}
+	return keys
+}
+
+func nearestKeys(tuples []*KeyValueTuple[int]) []string {
+	keys := []string{}
+	for _, tuple := range tuples {
+		keys = append(keys, tuple.Key)
+	}
+	return keys
+}
+
+func (s *MySuite) TestNearest(c *C) {
+	tree := New[int](0)
+	c.Check(tree.Nearest("abc", 3), IsNil)
+
+	for i, key := range []string{"abcdef", "abcxyz", "abd", "ab", "b", "ba", "abcd"} {
+		tree.Insert(key, i)
+	}
+	c.Check(nearestKeys(tree.Nearest("abcdzz", 3)), DeepEquals, []string{"abcd", "abcdef", "abcxyz"})
+	c.Check(nearestKeys(tree.Nearest("abc", 4)), DeepEquals, []string{"abcd", "abcdef", "abcxyz", "ab"})
+	c.Check(nearestKeys(tree.Nearest("bz", 2)), DeepEquals, []string{"b", "ba"})
+	c.Check(nearestKeys(tree.Nearest("zzz", 2)), DeepEquals, []string{"ab", "abcd"})
+	c.Check(tree.Nearest("abc", 0), IsNil)
+
+	tuples := tree.Nearest("ab", 1)
+	c.Assert(tuples, HasLen, 1)
+	c.Check(*tuples[0], Equals, KeyValueTuple[int]{"ab", 3})
+}
+
+func (s *MySuite) TestNearestRandom(c *C) {
+	rng := rand.New(rand.NewSource(39))
+	tree := New[int](0)
+	for i := 0; i < 500; i++ {
+		tree.Insert(randomKey(rng), i)
+	}
+	// Include keys ending in NULs, which end-of-key nodes separate
+	tree.Insert("ab\x00", -1)
+	tree.Insert("ab\x00\x00", -2)
+	for i := 0; i < 200; i++ {
+		query := randomKey(rng)
+		if i%10 == 0 {
+			query += "\x00"
+		}
+		for _, k := range []int{1, 3, 10, 1000} {
+			c.Check(nearestKeys(tree.Nearest(query, k)), DeepEquals, expectedNearest(tree, query, k),
+				Commentf("query=%q k=%d", query, k))
+		}
+	}
+}
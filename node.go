@@ -1,6 +1,10 @@
 package critbit
 
-func (node *internalNode) setChild(direction byte, id uint32, childType uint8) {
+import (
+	"math/bits"
+)
+
+func (node *internalNode) setChild(direction byte, id nodeIndex, childType uint8) {
 	node.child[direction] = id
 	node.setChildType(direction, childType)
 }
@@ -27,10 +31,63 @@ func (node *internalNode) getChildType(direction byte) uint8 {
 	return flags &^ kRightMask
 }
 
-// Returns the direction for the given key
-func (node *internalNode) direction(key string) byte {
-	if node.offset < uint16(len(key)) && key[node.offset]&node.bit != 0 {
+// Returns the direction for a key of keyBits bits, whose last byte may be
+// partial. At an end-of-key node, keys which end at the node's position
+// go left, and longer keys go right. A key which ends before the bit
+// which a node tests goes left, like a key whose bit is 0.
+func (node *internalNode) direction(key string, keyBits int64) byte {
+	// On a 32-bit architecture, a wide offset may not fit in an int
+	if uint64(node.offset) >= uint64(len(key)) {
+		return 0
+	}
+	if keyBits < 8*(int64(node.offset)+1) && keyBits <= node.position() {
+		// The key ends partway through the byte, before the node's bit
+		return 0
+	}
+	if isEndOfKey(node.bit) || key[node.offset]&node.bit != 0 {
 		return 1
 	}
 	return 0
 }
+
+// Returns the position of a node's test, in bits from the start of the
+// key: the bit which it tests, or the length at which keys end.
+func (node *internalNode) position() int64 {
+	return 8*int64(node.offset) + int64(bitIndex(node.bit))
+}
+
+// Returns the length of a byte key in bits. Bit lengths are int64s, so
+// that they don't overflow an int for long keys on 32-bit architectures.
+func bitLen(key string) int64 {
+	return 8 * int64(len(key))
+}
+
+// Returns the bit of an end-of-key node which separates the keys which
+// end after n bits of the byte at its offset, for n from 0 to 7, from
+// the keys which continue. For n of 0, this is kEndOfKey. Otherwise it
+// has two bits set, the last bit which the keys have and the next one,
+// so it can't be mistaken for a bit which a node tests.
+func endOfKeyBit(n int) byte {
+	if n == 0 {
+		return kEndOfKey
+	}
+	m := byte(0x80) >> n
+	return m<<1 | m
+}
+
+// Returns whether a node's bit marks where keys end, rather than being
+// the bit which it tests.
+func isEndOfKey(bit byte) bool {
+	return bit == kEndOfKey || bit&(bit-1) != 0
+}
+
+// Returns the number of bits of a byte which come before a node's bit
+func bitIndex(bit byte) int {
+	switch {
+	case bit == kEndOfKey:
+		return 0
+	case bit&(bit-1) != 0:
+		return bits.LeadingZeros8(bit) + 1
+	}
+	return bits.LeadingZeros8(bit)
+}
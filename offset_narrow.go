@@ -0,0 +1,12 @@
+//go:build !critbit_wideoffset
+
+package critbit
+
+// A keyOffset stores the offset of the critical byte within a key.
+// By default it is a uint16, which keeps internalNode at 12 bytes.
+// Build with the "critbit_wideoffset" tag to use a uint32 instead.
+type keyOffset = uint16
+
+// MaxStringLength is the maximum length of a key. Every offset within
+// a key of this length fits in a keyOffset.
+const MaxStringLength = 1 << 16
@@ -0,0 +1,12 @@
+//go:build critbit_wideoffset
+
+package critbit
+
+// A keyOffset stores the offset of the critical byte within a key.
+// The "critbit_wideoffset" build tag selects a uint32, which grows
+// internalNode to 16 bytes but allows keys of up to 4 GiB.
+type keyOffset = uint32
+
+// MaxStringLength is the maximum length of a key. Every offset within
+// a key of this length fits in a keyOffset.
+const MaxStringLength = 1 << 32
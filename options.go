@@ -0,0 +1,21 @@
+package critbit
+
+// An Option changes how New sets up a tree.
+type Option func(*config)
+
+// The options a tree was created with. Trees that are derived from
+// another tree, like the results of Split, share its config.
+type config struct {
+	keyArena          bool
+	arenaGarbageRatio float64
+	collation         Collation
+	subtreeSizes      bool
+}
+
+func newConfig(options []Option) config {
+	var cfg config
+	for _, option := range options {
+		option(&cfg)
+	}
+	return cfg
+}
@@ -0,0 +1,120 @@
+package critbit
+
+import (
+	"iter"
+)
+
+// IteratePrefix returns an iterator over the keys which start with a
+// prefix, and their values, in sorted order. Only the subtree under the
+// prefix is walked.
+func (tree *Critbit[T]) IteratePrefix(prefix string) iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		sortPrefix := tree.collate(prefix)
+		itemType, itemID, found := tree.findPrefixRoot(sortPrefix, bitLen(sortPrefix))
+		if !found {
+			return
+		}
+		tree.walkRefs(itemType, itemID, func(refNum nodeIndex) bool {
+			return yield(tree.refOriginalKey(refNum), tree.externalRefs[refNum].value)
+		})
+	}
+}
+
+// IteratePrefixAfter returns an iterator over the keys which start with
+// a prefix and come after a key, exclusive, and their values, in sorted
+// order. Passing the last key which IteratePrefix or IteratePrefixAfter
+// returned continues the iteration from where it stopped. If the tree has
+// a collation, the prefix and the key are compared through it.
+func (tree *Critbit[T]) IteratePrefixAfter(prefix string, after string) iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		sortPrefix := tree.collate(prefix)
+		lo, loBits := keyAfter(tree.collate(after))
+		if compareKeys(lo, loBits, sortPrefix, bitLen(sortPrefix)) < 0 {
+			lo, loBits = sortPrefix, bitLen(sortPrefix)
+		}
+		tree.walkSortKeyRange(lo, loBits, prefixEnd(sortPrefix), yield)
+	}
+}
+
+// Returns the smallest string which is greater than every string which
+// starts with the prefix, or "" if there is none.
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for len(end) > 0 && end[len(end)-1] == 0xff {
+		end = end[:len(end)-1]
+	}
+	if len(end) == 0 {
+		return ""
+	}
+	end[len(end)-1]++
+	return string(end)
+}
+
+// LongestPrefix finds the longest key in the tree which is a prefix of
+// a string, or is the string itself, and returns the KeyValueTuple, or nil.
+func (tree *Critbit[T]) LongestPrefix(key string) *KeyValueTuple[T] {
+	key = tree.collate(key)
+	refNums := tree.findPrefixRefs(key, bitLen(key))
+	if len(refNums) == 0 {
+		return nil
+	}
+	refNum := refNums[len(refNums)-1]
+	return &KeyValueTuple[T]{
+		Key:   tree.refOriginalKey(refNum),
+		Value: tree.externalRefs[refNum].value,
+	}
+}
+
+// PrefixesOf returns an iterator over the keys in the tree which are
+// prefixes of a string, including the string itself, and their values,
+// from the shortest to the longest.
+func (tree *Critbit[T]) PrefixesOf(key string) iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		sortKey := tree.collate(key)
+		for _, refNum := range tree.findPrefixRefs(sortKey, bitLen(sortKey)) {
+			if !yield(tree.refOriginalKey(refNum), tree.externalRefs[refNum].value) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the refNums of the keys which are prefixes of a key of keyBits
+// bits, from the shortest to the longest.
+//
+// A key K which is a prefix of the key goes the same way as the key at
+// every node which tests a bit within K. At the first node on the key's
+// path which tests a bit past the end of K, every key in the subtree
+// starts with K, so K, being the shortest of them, is the leftmost. So
+// the only candidates are the leftmost keys of the nodes on the key's
+// path, and the leaf at its end.
+func (tree *Critbit[T]) findPrefixRefs(key string, keyBits int64) []nodeIndex {
+	var refNums []nodeIndex
+	consider := func(refNum nodeIndex) {
+		if len(refNums) > 0 && refNums[len(refNums)-1] == refNum {
+			return
+		}
+		refKey := tree.refKey(refNum)
+		if hasBitPrefix(key, keyBits, refKey, tree.refBits(refNum, refKey)) {
+			refNums = append(refNums, refNum)
+		}
+	}
+
+	itemType := tree.rootItemType()
+	itemID := tree.rootItem
+	for itemType == kChildIntNode {
+		consider(tree.leftmostRef(itemType, itemID))
+		node := &tree.internalNodes[itemID]
+		if node.position() >= keyBits {
+			// Every key further down is longer than the key
+			return refNums
+		}
+		direction := node.direction(key, keyBits)
+		itemType = node.getChildType(direction)
+		itemID = node.child[direction]
+	}
+	if itemType == kChildExtRef {
+		consider(itemID)
+	}
+	return refNums
+}
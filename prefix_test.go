@@ -0,0 +1,102 @@
+package critbit
+
+import (
+	"strings"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+var prefixTable = []string{"", "a", "ab", "abc", "abd", "abcdef", "b", "ba", "bad", "badge", "x"}
+
+func (s *MySuite) TestIteratePrefix(c *C) {
+	tree := New[int](0)
+	for i, key := range prefixTable {
+		tree.Insert(key, i)
+	}
+	for _, prefix := range []string{"", "a", "ab", "abc", "abcd", "bad", "c", "x", "xy"} {
+		var expected, obtained []string
+		for _, key := range tree.Keys() {
+			if strings.HasPrefix(key, prefix) {
+				expected = append(expected, key)
+			}
+		}
+		for key := range tree.IteratePrefix(prefix) {
+			obtained = append(obtained, key)
+		}
+		c.Check(obtained, DeepEquals, expected, Commentf("prefix=%q", prefix))
+	}
+}
+
+func (s *MySuite) TestIteratePrefixAfter(c *C) {
+	tree := New[int](0, WithCollation(FoldASCII))
+	for i, key := range []string{"Apple", "Apricot", "avocado", "banana"} {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+	}
+	// Paging through the prefix, one key at a time
+	var keys []string
+	for key := range tree.IteratePrefix("A") {
+		keys = append(keys, key)
+		break
+	}
+	for {
+		next := seqKeys(tree.IteratePrefixAfter("A", keys[len(keys)-1]))
+		if next == nil {
+			break
+		}
+		keys = append(keys, next[0])
+	}
+	c.Check(keys, DeepEquals, []string{"Apple", "Apricot", "avocado"})
+
+	// A key before the prefix starts at the prefix
+	c.Check(seqKeys(tree.IteratePrefixAfter("b", "a")), DeepEquals, []string{"banana"})
+	c.Check(seqKeys(tree.IteratePrefixAfter("b", "Banana")), IsNil)
+}
+
+func (s *MySuite) TestPrefixEnd(c *C) {
+	c.Check(prefixEnd(""), Equals, "")
+	c.Check(prefixEnd("ab"), Equals, "ac")
+	c.Check(prefixEnd("a\xff\xff"), Equals, "b")
+	c.Check(prefixEnd("\xff"), Equals, "")
+}
+
+func (s *MySuite) TestLongestPrefix(c *C) {
+	tree := New[int](0)
+	c.Check(tree.LongestPrefix("abc"), IsNil)
+	for i, key := range prefixTable[1:] {
+		tree.Insert(key, i)
+	}
+
+	for _, query := range []string{"", "a", "abc", "abcd", "abcdefg", "abe", "baddie", "badges", "c", "xyz"} {
+		var expected []string
+		for _, key := range tree.Keys() {
+			if strings.HasPrefix(query, key) {
+				expected = append(expected, key)
+			}
+		}
+		var obtained []string
+		for key := range tree.PrefixesOf(query) {
+			obtained = append(obtained, key)
+		}
+		c.Check(obtained, DeepEquals, expected, Commentf("query=%q", query))
+
+		kvt := tree.LongestPrefix(query)
+		if expected == nil {
+			c.Check(kvt, IsNil, Commentf("query=%q", query))
+		} else {
+			c.Assert(kvt, NotNil, Commentf("query=%q", query))
+			c.Check(kvt.Key, Equals, expected[len(expected)-1])
+		}
+	}
+}
+
+func (s *MySuite) TestLongestPrefixCollation(c *C) {
+	tree := New[int](0, WithCollation(FoldASCII))
+	tree.Insert("Net", 1)
+	tree.Insert("NetFlix", 2)
+	kvt := tree.LongestPrefix("netflixing")
+	c.Assert(kvt, NotNil)
+	c.Check(kvt.Key, Equals, "NetFlix")
+	c.Check(kvt.Value, Equals, 2)
+}
@@ -0,0 +1,108 @@
+package critbit
+
+import (
+	"iter"
+)
+
+// IterateRange returns an iterator over the keys from start, inclusive,
+// to end, exclusive, and their values, in sorted order. If end is "", the
+// keys from start to the last key are returned. Only the subtrees which
+// hold keys in the range are walked.
+func (tree *Critbit[T]) IterateRange(start string, end string) iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		// The arguments are captured, so collating them in place would
+		// collate them again each time the iterator is used
+		lo, hi := tree.collate(start), end
+		if hi != "" {
+			hi = tree.collate(hi)
+		}
+		tree.walkSortKeyRange(lo, bitLen(lo), hi, yield)
+	}
+}
+
+// IterateAfter returns an iterator over the keys after a key, exclusive,
+// to end, exclusive, and their values, in sorted order. If end is "", the
+// keys up to the last key are returned. If the tree has a collation, the
+// keys are compared by their sort keys, so passing the last key which an
+// iterator returned continues it from where it stopped, even though keys
+// which aren't in the tree may sort in between.
+func (tree *Critbit[T]) IterateAfter(after string, end string) iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		hi := end
+		if hi != "" {
+			hi = tree.collate(hi)
+		}
+		lo, loBits := keyAfter(tree.collate(after))
+		tree.walkSortKeyRange(lo, loBits, hi, yield)
+	}
+}
+
+// Returns the first key after a key, which is the key followed by a 0
+// bit, and its length in bits
+func keyAfter(key string) (string, int64) {
+	return key + "\x00", bitLen(key) + 1
+}
+
+// Yields the keys whose sort keys are from lo, of loBits bits, inclusive,
+// to hi, exclusive, or to the last key if hi is "".
+func (tree *Critbit[T]) walkSortKeyRange(lo string, loBits int64, hi string, yield func(string, T) bool) {
+	items := tree.findRangeStart(lo, loBits)
+	// The subtrees are in reverse key order
+	for i := len(items) - 1; i >= 0; i-- {
+		done := false
+		tree.walkRefs(items[i].itemType, items[i].itemID, func(refNum nodeIndex) bool {
+			if hi != "" && tree.compareRef(refNum, hi, bitLen(hi)) >= 0 {
+				done = true
+				return false
+			}
+			if !yield(tree.refOriginalKey(refNum), tree.externalRefs[refNum].value) {
+				done = true
+				return false
+			}
+			return true
+		})
+		if done {
+			return
+		}
+	}
+}
+
+// Returns the subtrees which hold the keys which are not less than start,
+// of startBits bits, from the one with the largest keys to the one with
+// the smallest.
+//
+// The keys greater than start are the ones to the right of start's path,
+// and if start isn't in the tree, the subtree at the point where start
+// would be inserted, if its keys are greater than start.
+func (tree *Critbit[T]) findRangeStart(start string, startBits int64) []walkerItem {
+	if tree.numExternalRefs == 0 {
+		return nil
+	}
+	bestRefNum := tree.findBestExternalReference(start, startBits)
+	identical, off, bit, ndir := tree.findCriticalBit(bestRefNum, start, startBits)
+
+	var items []walkerItem
+	itemType := tree.rootItemType()
+	itemID := tree.rootItem
+	for itemType == kChildIntNode {
+		node := &tree.internalNodes[itemID]
+		if !identical && (node.offset > off || node.offset == off && bitRank(node.bit) < bitRank(bit)) {
+			// Start would be inserted above this node, so the keys
+			// under it are on one side of start.
+			break
+		}
+		direction := node.direction(start, startBits)
+		if direction == kDirectionLeft {
+			items = append(items, walkerItem{
+				itemType: node.getChildType(kDirectionRight),
+				itemID:   node.child[kDirectionRight],
+			})
+		}
+		itemType = node.getChildType(direction)
+		itemID = node.child[direction]
+	}
+	if identical || ndir == kDirectionRight {
+		items = append(items, walkerItem{itemType: itemType, itemID: itemID})
+	}
+	return items
+}
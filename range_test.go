@@ -0,0 +1,125 @@
+package critbit
+
+import (
+	"iter"
+	"math/rand"
+	"strings"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func rangeKeys(tree *Critbit[int], start string, end string) []string {
+	return seqKeys(tree.IterateRange(start, end))
+}
+
+func seqKeys(seq iter.Seq2[string, int]) []string {
+	var keys []string
+	for key := range seq {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *MySuite) TestIterateRange(c *C) {
+	tree := New[int](0)
+	c.Check(rangeKeys(tree, "", ""), IsNil)
+
+	table := []string{"", "a", "a\x00", "ab", "abc", "b", "ba", "c"}
+	for i, key := range table {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+	}
+	c.Check(rangeKeys(tree, "", ""), DeepEquals, table)
+	c.Check(rangeKeys(tree, "a", "b"), DeepEquals, []string{"a", "a\x00", "ab", "abc"})
+	c.Check(rangeKeys(tree, "a\x00", "abc"), DeepEquals, []string{"a\x00", "ab"})
+	c.Check(rangeKeys(tree, "aa", "b\x00"), DeepEquals, []string{"ab", "abc", "b"})
+	c.Check(rangeKeys(tree, "abd", ""), DeepEquals, []string{"b", "ba", "c"})
+	c.Check(rangeKeys(tree, "c", ""), DeepEquals, []string{"c"})
+	c.Check(rangeKeys(tree, "c\x00", ""), IsNil)
+	c.Check(rangeKeys(tree, "b", "b"), IsNil)
+	c.Check(rangeKeys(tree, "b", "a"), IsNil)
+
+	// Stopping early
+	for key := range tree.IterateRange("a", "") {
+		c.Check(key, Equals, "a")
+		break
+	}
+}
+
+// Compares IterateRange, IterateAfter and IteratePrefixAfter with
+// filtering all the keys, for random ranges
+func (s *MySuite) TestIterateRangeRandom(c *C) {
+	rng := rand.New(rand.NewSource(1))
+	randomKey := func() string {
+		key := make([]byte, rng.Intn(4))
+		for i := range key {
+			key[i] = "aAbB\x00"[rng.Intn(5)]
+		}
+		return string(key)
+	}
+	for _, options := range [][]Option{nil, {WithCollation(FoldASCII)}} {
+		tree := New[int](0, options...)
+		for i := 0; i < 300; i++ {
+			_, err := tree.Insert(randomKey(), i)
+			c.Assert(err, IsNil)
+		}
+		keys := tree.Keys()
+		for i := 0; i < 500; i++ {
+			start, end := randomKey(), randomKey()
+			if i%10 == 0 {
+				end = ""
+			}
+			var expected []string
+			for _, original := range keys {
+				key := tree.collate(original)
+				if key >= tree.collate(start) && (end == "" || key < tree.collate(end)) {
+					expected = append(expected, original)
+				}
+			}
+			c.Check(rangeKeys(tree, start, end), DeepEquals, expected, Commentf("%q to %q", start, end))
+
+			var after, prefixAfter []string
+			prefix := start[:min(len(start), 1)]
+			for _, original := range keys {
+				key := tree.collate(original)
+				if key > tree.collate(start) && (end == "" || key < tree.collate(end)) {
+					after = append(after, original)
+				}
+				if key > tree.collate(end) && strings.HasPrefix(key, tree.collate(prefix)) {
+					prefixAfter = append(prefixAfter, original)
+				}
+			}
+			c.Check(seqKeys(tree.IterateAfter(start, end)), DeepEquals, after,
+				Commentf("after %q to %q", start, end))
+			c.Check(seqKeys(tree.IteratePrefixAfter(prefix, end)), DeepEquals, prefixAfter,
+				Commentf("prefix %q after %q", prefix, end))
+		}
+	}
+}
+
+// The same iterator can be ranged over more than once, even if the
+// collation gives a different key when applied twice.
+func (s *MySuite) TestIterateRangeReuse(c *C) {
+	// Not idempotent: "a" becomes "b", and "b" becomes "c"
+	shift := func(key string) []byte {
+		collated := []byte(key)
+		for i := range collated {
+			collated[i]++
+		}
+		return collated
+	}
+	tree := New[int](0, WithCollation(shift))
+	for i, key := range []string{"a", "b", "c", "d"} {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+	}
+	seq := tree.IterateRange("b", "d")
+	for i := 0; i < 3; i++ {
+		var keys []string
+		for key := range seq {
+			keys = append(keys, key)
+		}
+		c.Check(keys, DeepEquals, []string{"b", "c"})
+	}
+}
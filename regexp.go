@@ -0,0 +1,219 @@
+package critbit
+
+import (
+	"iter"
+	"regexp"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// MatchRegexp returns an iterator over the keys that the regular
+// expression matches, in sorted order. As with re.MatchString, the
+// regexp may match any part of a key, so to avoid testing every key,
+// anchor it at the start with ^ or \A. A regexp from CompilePOSIX, whose
+// ^ matches at the start of any line, is never treated as anchored, and
+// neither is a ^ regexp which CompilePOSIX could have compiled, unless
+// it starts with a literal string; use \A to be sure.
+//
+// For an anchored regexp, the walk steps the regexp's program over the
+// bytes which all the keys of a subtree share, and skips the subtree
+// once no match is possible. The keys which survive are tested with
+// re.MatchString. If the tree has a collation, the regexp is matched
+// against the keys as they were inserted, and so every key is tested.
+func (tree *Critbit[T]) MatchRegexp(re *regexp.Regexp) iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		if tree.numExternalRefs == 0 {
+			return
+		}
+		rootType := tree.rootItemType()
+		match := func(refNum nodeIndex) bool {
+			key := tree.refOriginalKey(refNum)
+			if !re.MatchString(key) {
+				return true
+			}
+			return yield(key, tree.externalRefs[refNum].value)
+		}
+
+		prog := anchoredProgram(re)
+		if prog == nil || tree.config.collation != nil {
+			tree.walkRefs(rootType, tree.rootItem, match)
+			return
+		}
+
+		// Jump to the subtree of the literal prefix, if there is one
+		prefix, _ := re.LiteralPrefix()
+		itemType, itemID, found := tree.findPrefixRoot(prefix, bitLen(prefix))
+		if !found {
+			return
+		}
+		walker := &regexpWalker[T]{
+			tree:    tree,
+			prog:    prog,
+			match:   match,
+			onStack: make([]bool, len(prog.Inst)),
+		}
+		start := walker.addThread(nil, uint32(prog.Start))
+		walker.clearOnStack()
+		leftmost := tree.refKey(tree.leftmostRef(itemType, itemID))
+		walker.walkItem(itemType, itemID, leftmost, 0, start)
+	}
+}
+
+// Returns the compiled program of a regexp which is anchored to the
+// start of the text, or nil if the regexp is not anchored, or may not
+// be.
+//
+// The program comes from parsing the expression with Perl flags, as
+// regexp.Compile does. regexp.CompilePOSIX parses it without OneLine, so
+// that ^ matches at the start of every line, and the regexp doesn't say
+// which it was compiled with. An expression which doesn't parse with
+// POSIX flags must have been compiled with Perl flags. Otherwise, since
+// its Perl parse is anchored, a POSIX regexp would start with a
+// beginning of line assertion, which leaves it without a literal
+// prefix, so a literal prefix shows that the flags were Perl's.
+func anchoredProgram(re *regexp.Regexp) *syntax.Prog {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	parsed = parsed.Simplify()
+	if !anchoredAtStart(parsed) {
+		return nil
+	}
+	if _, err := syntax.Parse(re.String(), syntax.POSIX); err == nil {
+		if prefix, _ := re.LiteralPrefix(); prefix == "" {
+			return nil
+		}
+	}
+	prog, err := syntax.Compile(parsed)
+	if err != nil {
+		return nil
+	}
+	return prog
+}
+
+func anchoredAtStart(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpBeginText:
+		return true
+	case syntax.OpConcat, syntax.OpCapture:
+		return len(re.Sub) > 0 && anchoredAtStart(re.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if !anchoredAtStart(sub) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// A regexpWalker runs the regexp's program as a set of threads, one rune
+// at a time, along the walk of the tree. Empty-width assertions, like
+// \b or $, are assumed to succeed. So the set of threads may be larger
+// than it should be, but it is empty only when no key can match.
+type regexpWalker[T any] struct {
+	tree    *Critbit[T]
+	prog    *syntax.Prog
+	match   func(refNum nodeIndex) bool
+	onStack []bool
+}
+
+// Adds the thread at pc, and the threads it leads to without consuming
+// a rune, to the set. The caller must clear onStack afterwards.
+func (walker *regexpWalker[T]) addThread(threads []uint32, pc uint32) []uint32 {
+	if walker.onStack[pc] {
+		return threads
+	}
+	walker.onStack[pc] = true
+	inst := &walker.prog.Inst[pc]
+	switch inst.Op {
+	case syntax.InstAlt, syntax.InstAltMatch:
+		threads = walker.addThread(threads, inst.Out)
+		threads = walker.addThread(threads, inst.Arg)
+	case syntax.InstCapture, syntax.InstNop, syntax.InstEmptyWidth:
+		threads = walker.addThread(threads, inst.Out)
+	case syntax.InstFail:
+		// no-op
+	default:
+		// InstMatch, and the instructions which consume a rune
+		threads = append(threads, pc)
+	}
+	return threads
+}
+
+func (walker *regexpWalker[T]) clearOnStack() {
+	for i := range walker.onStack {
+		walker.onStack[i] = false
+	}
+}
+
+// Returns the threads after consuming a rune. The regexp need not match
+// all of a key, so a thread which has matched stays.
+func (walker *regexpWalker[T]) step(threads []uint32, r rune) []uint32 {
+	var next []uint32
+	for _, pc := range threads {
+		inst := &walker.prog.Inst[pc]
+		var ok bool
+		switch inst.Op {
+		case syntax.InstMatch:
+			next = walker.addThread(next, pc)
+		case syntax.InstRune:
+			ok = inst.MatchRune(r)
+		case syntax.InstRune1:
+			ok = r == inst.Rune[0]
+		case syntax.InstRuneAny:
+			ok = true
+		case syntax.InstRuneAnyNotNL:
+			ok = r != '\n'
+		}
+		if ok {
+			next = walker.addThread(next, inst.Out)
+		}
+	}
+	walker.clearOnStack()
+	return next
+}
+
+// Walks one item. The threads are the state after the first pos bytes
+// of the leftmost key, which all keys under the item share. Returns false
+// if the caller stopped the iteration.
+func (walker *regexpWalker[T]) walkItem(itemType byte, itemID nodeIndex, leftmost string,
+	pos int, threads []uint32) bool {
+	tree := walker.tree
+	if itemType == kChildExtRef {
+		key := tree.refKey(itemID)
+		if _, threads = walker.stepOver(key, pos, threads); len(threads) == 0 {
+			return true
+		}
+		return walker.match(itemID)
+	}
+
+	node := &tree.internalNodes[itemID]
+	prefix := leftmost[:min(int(node.offset), len(leftmost))]
+	if pos, threads = walker.stepOver(prefix, pos, threads); len(threads) == 0 {
+		// No key in this subtree can match
+		return true
+	}
+
+	leftType := node.getChildType(kDirectionLeft)
+	if !walker.walkItem(leftType, node.child[kDirectionLeft], leftmost, pos, threads) {
+		return false
+	}
+	rightType := node.getChildType(kDirectionRight)
+	leftmost = tree.refKey(tree.leftmostRef(rightType, node.child[kDirectionRight]))
+	return walker.walkItem(rightType, node.child[kDirectionRight], leftmost, pos, threads)
+}
+
+// Steps the threads over the runes of s, starting at pos. Only whole
+// runes are stepped over; the rest of a rune which is split by a node's
+// offset is left for its children. Returns the new pos and threads.
+func (walker *regexpWalker[T]) stepOver(s string, pos int, threads []uint32) (int, []uint32) {
+	for pos < len(s) && utf8.FullRuneInString(s[pos:]) && len(threads) > 0 {
+		r, size := utf8.DecodeRuneInString(s[pos:])
+		pos += size
+		threads = walker.step(threads, r)
+	}
+	return pos, threads
+}
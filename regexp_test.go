@@ -0,0 +1,116 @@
+package critbit
+
+import (
+	"regexp"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+var regexpTable = []string{"", "a", "ab", "abc", "abd", "abé", "b", "ba", "cpu_seconds",
+	"cpu_usage", "mem_usage", "mem_usage_bytes", "net_rx", "net_tx", "x\ny", "ABC"}
+
+func (s *MySuite) TestMatchRegexp(c *C) {
+	tree := New[int](0)
+	for i, key := range regexpTable {
+		tree.Insert(key, i)
+	}
+
+	for _, expr := range []string{"", "^", "^$", "b", "^ab", "^ab$", "^a.c", "^(abc|abd)$", "^ab[^c]",
+		"^abé$", "^(?i)abc$", "^(cpu|mem)_usage", "_usage$", "^.*_usage$", `^\w+_(rx|tx)\b`,
+		"^x.y", "^(?s)x.y", "^[a-c]+$", `\Anet`, "^zzz", "^(a|b)$|^net"} {
+		re := regexp.MustCompile(expr)
+		var expected []string
+		for _, key := range tree.Keys() {
+			if re.MatchString(key) {
+				expected = append(expected, key)
+			}
+		}
+		var found []string
+		for key, value := range tree.MatchRegexp(re) {
+			found = append(found, key)
+			c.Check(value, Equals, indexOf(regexpTable, key))
+		}
+		c.Check(found, DeepEquals, expected, Commentf("%q", expr))
+	}
+}
+
+func indexOf(table []string, key string) int {
+	for i, k := range table {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MySuite) TestMatchRegexpPrunes(c *C) {
+	tree := New[int](0)
+	for i, key := range regexpTable {
+		tree.Insert(key, i)
+	}
+
+	re := regexp.MustCompile(`\A(cpu|mem)_usage`)
+	var tested []string
+	walker := &regexpWalker[int]{
+		tree: tree,
+		prog: anchoredProgram(re),
+		match: func(refNum nodeIndex) bool {
+			tested = append(tested, tree.refKey(refNum))
+			return true
+		},
+	}
+	walker.onStack = make([]bool, len(walker.prog.Inst))
+	start := walker.addThread(nil, uint32(walker.prog.Start))
+	walker.clearOnStack()
+	leftmost := tree.refKey(tree.leftmostRef(tree.rootItemType(), tree.rootItem))
+	walker.walkItem(tree.rootItemType(), tree.rootItem, leftmost, 0, start)
+	// The empty key can't be ruled out until the regexp is run on it
+	c.Check(tested, DeepEquals, []string{"", "cpu_usage", "mem_usage", "mem_usage_bytes"})
+
+	c.Check(anchoredProgram(regexp.MustCompile("usage")), IsNil)
+	c.Check(anchoredProgram(regexp.MustCompile("(?m)^usage")), IsNil)
+	c.Check(anchoredProgram(regexp.MustCompile("^a|b")), IsNil)
+	c.Check(anchoredProgram(regexp.MustCompile(`(^a)|\Ab`)), NotNil)
+	c.Check(anchoredProgram(regexp.MustCompile("^ab")), NotNil)
+
+	// CompilePOSIX could have compiled these, and they have no literal
+	// prefix to show that it didn't
+	c.Check(anchoredProgram(regexp.MustCompile("(^a)|^b")), IsNil)
+	c.Check(anchoredProgram(regexp.MustCompile("^(cpu|mem)")), IsNil)
+	c.Check(anchoredProgram(regexp.MustCompilePOSIX("^b")), IsNil)
+	c.Check(anchoredProgram(regexp.MustCompilePOSIX("^ab")), IsNil)
+}
+
+func (s *MySuite) TestMatchRegexpPOSIX(c *C) {
+	// In a POSIX regexp, ^ matches at the start of every line
+	tree := New[int](0)
+	for i, key := range []string{"a\nbc", "bc", "x\nbq"} {
+		tree.Insert(key, i)
+	}
+	for _, re := range []*regexp.Regexp{regexp.MustCompilePOSIX("^b"), regexp.MustCompilePOSIX("^bc|^bq")} {
+		var found []string
+		for key := range tree.MatchRegexp(re) {
+			found = append(found, key)
+		}
+		c.Check(found, DeepEquals, []string{"a\nbc", "bc", "x\nbq"}, Commentf("%s", re))
+	}
+
+	// The same expressions compiled with Perl flags are anchored
+	var found []string
+	for key := range tree.MatchRegexp(regexp.MustCompile("^b")) {
+		found = append(found, key)
+	}
+	c.Check(found, DeepEquals, []string{"bc"})
+}
+
+func (s *MySuite) TestMatchRegexpCollation(c *C) {
+	tree := New[int](0, WithCollation(FoldASCII))
+	tree.Insert("Alice", 1)
+	tree.Insert("alfred", 2)
+	var found []string
+	for key := range tree.MatchRegexp(regexp.MustCompile("^A")) {
+		found = append(found, key)
+	}
+	c.Check(found, DeepEquals, []string{"Alice"})
+}
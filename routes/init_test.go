@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"testing"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+// Hook gocheck into the "go test" runner
+func Test(t *testing.T) { TestingT(t) }
+
+type MySuite struct{}
+
+var _ = Suite(&MySuite{})
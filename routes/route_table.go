@@ -0,0 +1,167 @@
+// Package routes provides an IP routing table on top of a critbit tree.
+package routes
+
+import (
+	"fmt"
+	"iter"
+	"net/netip"
+
+	"github.com/gilramir/critbit"
+	"github.com/pkg/errors"
+)
+
+// A route's key is a byte naming the address family, followed by the
+// prefix's bits, and is stored with the tree's *Bits methods, so its
+// length is 8 bits plus the prefix length. So the keys of the routes
+// which cover an address are exactly the prefixes of the address's key.
+const (
+	kFamily4 = '4'
+	kFamily6 = '6'
+)
+
+// A RouteTable maps IPv4 and IPv6 prefixes to values of type T.
+// An IPv4-mapped IPv6 address or prefix is treated as an IPv6 one.
+type RouteTable[T any] struct {
+	tree *critbit.Critbit[T]
+}
+
+// NewRouteTable allocates a new RouteTable. The capacityRoutes argument
+// is passed to critbit.New.
+func NewRouteTable[T any](capacityRoutes int) *RouteTable[T] {
+	return &RouteTable[T]{
+		tree: critbit.New[T](capacityRoutes),
+	}
+}
+
+// Tree returns the underlying tree, whose keys are the encoded prefixes,
+// stored as bit keys.
+func (rt *RouteTable[T]) Tree() *critbit.Critbit[T] {
+	return rt.tree
+}
+
+// Length returns the number of routes in the table.
+func (rt *RouteTable[T]) Length() int {
+	return rt.tree.Length()
+}
+
+// Insert inserts a route, like Critbit.Insert. The host bits of the
+// prefix are ignored, so 10.1.2.3/8 is the same route as 10.0.0.0/8.
+// An error is returned if the prefix is invalid.
+func (rt *RouteTable[T]) Insert(prefix netip.Prefix, value T) (bool, error) {
+	if !prefix.IsValid() {
+		return false, errors.Errorf("Invalid prefix %v", prefix)
+	}
+	key, bitLength := encodePrefix(prefix)
+	return rt.tree.InsertBits(key, bitLength, value)
+}
+
+// Upsert inserts or updates a route, like Critbit.Upsert.
+// An error is returned if the prefix is invalid.
+func (rt *RouteTable[T]) Upsert(prefix netip.Prefix, value T) error {
+	if !prefix.IsValid() {
+		return errors.Errorf("Invalid prefix %v", prefix)
+	}
+	key, bitLength := encodePrefix(prefix)
+	return rt.tree.UpsertBits(key, bitLength, value)
+}
+
+// Get finds the route for exactly this prefix, and returns its value.
+func (rt *RouteTable[T]) Get(prefix netip.Prefix) (T, bool) {
+	if !prefix.IsValid() {
+		var nilVal T
+		return nilVal, false
+	}
+	key, bitLength := encodePrefix(prefix)
+	return rt.tree.GetBits(key, bitLength)
+}
+
+// Delete removes the route for exactly this prefix.
+func (rt *RouteTable[T]) Delete(prefix netip.Prefix) bool {
+	if !prefix.IsValid() {
+		return false
+	}
+	key, bitLength := encodePrefix(prefix)
+	return rt.tree.DeleteBits(key, bitLength)
+}
+
+// Lookup finds the longest prefix in the table which contains the
+// address, and returns the prefix and its value.
+func (rt *RouteTable[T]) Lookup(addr netip.Addr) (netip.Prefix, T, bool) {
+	var nilVal T
+	if !addr.IsValid() {
+		return netip.Prefix{}, nilVal, false
+	}
+	key, bitLength := encodePrefix(netip.PrefixFrom(addr, addr.BitLen()))
+	route, value, found := rt.tree.LongestPrefixBits(key, bitLength)
+	if !found {
+		return netip.Prefix{}, nilVal, false
+	}
+	return decodePrefix(route), value, true
+}
+
+// Covering returns an iterator over the routes whose prefixes contain
+// the prefix, including the prefix itself, from the shortest prefix to
+// the longest.
+func (rt *RouteTable[T]) Covering(prefix netip.Prefix) iter.Seq2[netip.Prefix, T] {
+	if !prefix.IsValid() {
+		return func(yield func(netip.Prefix, T) bool) {}
+	}
+	key, bitLength := encodePrefix(prefix)
+	return decodeRoutes(rt.tree.PrefixesOfBits(key, bitLength))
+}
+
+// Covered returns an iterator over the routes whose prefixes are
+// contained by the prefix, including the prefix itself. A prefix comes
+// before the prefixes it contains, and the lower half of a prefix before
+// the upper half.
+func (rt *RouteTable[T]) Covered(prefix netip.Prefix) iter.Seq2[netip.Prefix, T] {
+	if !prefix.IsValid() {
+		return func(yield func(netip.Prefix, T) bool) {}
+	}
+	key, bitLength := encodePrefix(prefix)
+	return decodeRoutes(rt.tree.IteratePrefixBits(key, bitLength))
+}
+
+// IterateItems returns an iterator over all the routes, IPv4 routes
+// first, in the same order as Covered.
+func (rt *RouteTable[T]) IterateItems() iter.Seq2[netip.Prefix, T] {
+	return decodeRoutes(rt.tree.IterateBits())
+}
+
+func decodeRoutes[T any](items iter.Seq2[critbit.BitKey, T]) iter.Seq2[netip.Prefix, T] {
+	return func(yield func(netip.Prefix, T) bool) {
+		for key, value := range items {
+			if !yield(decodePrefix(key), value) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the key of a prefix, and its length in bits. The tree ignores
+// the host bits, past the length.
+func encodePrefix(prefix netip.Prefix) ([]byte, int) {
+	addr := prefix.Addr()
+	family := byte(kFamily6)
+	if addr.Is4() {
+		family = kFamily4
+	}
+	return append([]byte{family}, addr.AsSlice()...), 8 + prefix.Bits()
+}
+
+// Every key in the tree was encoded by encodePrefix, so failing to decode
+// one is a bug.
+func decodePrefix(key critbit.BitKey) netip.Prefix {
+	var addrBytes [16]byte
+	copy(addrBytes[:], key.Bytes[1:])
+	var addr netip.Addr
+	switch key.Bytes[0] {
+	case kFamily4:
+		addr = netip.AddrFrom4([4]byte(addrBytes[:4]))
+	case kFamily6:
+		addr = netip.AddrFrom16(addrBytes)
+	default:
+		panic(fmt.Sprintf("Can't decode stored key %q", key.Bytes))
+	}
+	return netip.PrefixFrom(addr, key.Length-8)
+}
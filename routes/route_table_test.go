@@ -0,0 +1,110 @@
+package routes
+
+import (
+	"net/netip"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func newTestTable(c *C, prefixes ...string) *RouteTable[string] {
+	rt := NewRouteTable[string](len(prefixes))
+	for _, p := range prefixes {
+		ok, err := rt.Insert(netip.MustParsePrefix(p), p)
+		c.Assert(err, IsNil)
+		c.Assert(ok, Equals, true, Commentf("%s", p))
+	}
+	return rt
+}
+
+func collectRoutes(routes func(func(netip.Prefix, string) bool)) []string {
+	var result []string
+	for prefix, value := range routes {
+		if prefix.String() != value {
+			panic(prefix.String() + " has value " + value)
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+func (s *MySuite) TestLookup(c *C) {
+	rt := newTestTable(c, "0.0.0.0/0", "10.0.0.0/8", "10.2.0.0/23", "10.2.0.0/24",
+		"10.2.1.128/25", "192.168.1.7/32", "2001:db8::/32", "2001:db8:1::/48")
+
+	lookups := []struct {
+		addr     string
+		expected string
+	}{
+		{"10.2.0.9", "10.2.0.0/24"},
+		{"10.2.1.9", "10.2.0.0/23"},
+		{"10.2.1.200", "10.2.1.128/25"},
+		{"10.2.2.1", "10.0.0.0/8"},
+		{"10.255.255.255", "10.0.0.0/8"},
+		{"11.0.0.0", "0.0.0.0/0"},
+		{"192.168.1.7", "192.168.1.7/32"},
+		{"192.168.1.6", "0.0.0.0/0"},
+		{"2001:db8:1::1", "2001:db8:1::/48"},
+		{"2001:db8:2::1", "2001:db8::/32"},
+		{"::ffff:10.2.0.9", ""},
+	}
+	for _, lookup := range lookups {
+		prefix, value, found := rt.Lookup(netip.MustParseAddr(lookup.addr))
+		c.Check(found, Equals, lookup.expected != "", Commentf("%s", lookup.addr))
+		c.Check(value, Equals, lookup.expected, Commentf("%s", lookup.addr))
+		if found {
+			c.Check(prefix.String(), Equals, lookup.expected)
+		}
+	}
+	_, _, found := rt.Lookup(netip.Addr{})
+	c.Check(found, Equals, false)
+}
+
+func (s *MySuite) TestCoveringAndCovered(c *C) {
+	rt := newTestTable(c, "0.0.0.0/0", "10.0.0.0/8", "10.2.0.0/23", "10.2.0.0/24",
+		"10.2.1.128/25", "10.3.0.0/16", "2001:db8::/32")
+
+	c.Check(collectRoutes(rt.Covering(netip.MustParsePrefix("10.2.1.130/32"))), DeepEquals,
+		[]string{"0.0.0.0/0", "10.0.0.0/8", "10.2.0.0/23", "10.2.1.128/25"})
+	c.Check(collectRoutes(rt.Covering(netip.MustParsePrefix("10.2.0.0/23"))), DeepEquals,
+		[]string{"0.0.0.0/0", "10.0.0.0/8", "10.2.0.0/23"})
+	c.Check(collectRoutes(rt.Covering(netip.MustParsePrefix("2001:db8::/16"))), IsNil)
+
+	c.Check(collectRoutes(rt.Covered(netip.MustParsePrefix("10.0.0.0/8"))), DeepEquals,
+		[]string{"10.0.0.0/8", "10.2.0.0/23", "10.2.0.0/24", "10.2.1.128/25", "10.3.0.0/16"})
+	c.Check(collectRoutes(rt.Covered(netip.MustParsePrefix("10.2.1.0/24"))), DeepEquals,
+		[]string{"10.2.1.128/25"})
+	c.Check(collectRoutes(rt.Covered(netip.MustParsePrefix("::/0"))), DeepEquals,
+		[]string{"2001:db8::/32"})
+	c.Check(collectRoutes(rt.IterateItems()), HasLen, 7)
+}
+
+func (s *MySuite) TestInsertDelete(c *C) {
+	rt := newTestTable(c, "10.0.0.0/8")
+
+	// The host bits are ignored
+	ok, err := rt.Insert(netip.MustParsePrefix("10.1.2.3/8"), "other")
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, false)
+	c.Check(rt.Upsert(netip.MustParsePrefix("10.1.2.3/8"), "updated"), IsNil)
+	value, found := rt.Get(netip.MustParsePrefix("10.0.0.0/8"))
+	c.Check(found, Equals, true)
+	c.Check(value, Equals, "updated")
+
+	_, err = rt.Insert(netip.Prefix{}, "bad")
+	c.Check(err, NotNil)
+
+	// The prefixes are stored packed, not one byte per bit
+	ok, err = rt.Insert(netip.MustParsePrefix("10.128.0.0/9"), "half")
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, true)
+	c.Check(rt.Tree().Keys(), DeepEquals, []string{"4\x0a", "4\x0a\x80"})
+	c.Check(rt.Tree().Validate(), IsNil)
+	c.Check(rt.Delete(netip.MustParsePrefix("10.128.0.0/9")), Equals, true)
+
+	c.Check(rt.Delete(netip.MustParsePrefix("10.0.0.0/9")), Equals, false)
+	c.Check(rt.Delete(netip.MustParsePrefix("10.0.0.0/8")), Equals, true)
+	c.Check(rt.Length(), Equals, 0)
+	_, _, found = rt.Lookup(netip.MustParseAddr("10.0.0.1"))
+	c.Check(found, Equals, false)
+}
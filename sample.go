@@ -0,0 +1,126 @@
+package critbit
+
+import (
+	"math/rand"
+)
+
+// WithSubtreeSizes makes the tree keep the number of keys under each
+// internal node, so that RandomKey and Sample find each key in O(depth),
+// instead of walking the keys in O(n). Every Insert and Delete then
+// updates the counts on the path to its key.
+func WithSubtreeSizes() Option {
+	return func(cfg *config) {
+		cfg.subtreeSizes = true
+	}
+}
+
+// RandomKey returns a key chosen uniformly at random, with its value, or
+// nil if the tree is empty. If the tree was created with
+// WithSubtreeSizes, this takes O(depth); otherwise it walks the keys, in
+// O(n). It doesn't change the tree, so it can run alongside other reads.
+func (tree *Critbit[T]) RandomKey(rng *rand.Rand) *KeyValueTuple[T] {
+	if tree.numExternalRefs == 0 {
+		return nil
+	}
+	refNums := tree.refsAtRanks([]int{rng.Intn(tree.numExternalRefs)})
+	return tree.keyValueTupleAt(refNums[0])
+}
+
+// Sample returns k different keys chosen uniformly at random, with their
+// values, in random order. If the tree has fewer than k keys, all of them
+// are returned. With WithSubtreeSizes, each key takes O(depth) to find;
+// otherwise, the keys are found in one walk, in O(n).
+func (tree *Critbit[T]) Sample(k int, rng *rand.Rand) []*KeyValueTuple[T] {
+	n := tree.numExternalRefs
+	k = min(k, n)
+	if k <= 0 {
+		return nil
+	}
+
+	// Floyd's algorithm chooses k distinct ranks
+	chosen := make(map[int]bool, k)
+	ranks := make([]int, 0, k)
+	for j := n - k; j < n; j++ {
+		rank := rng.Intn(j + 1)
+		if chosen[rank] {
+			rank = j
+		}
+		chosen[rank] = true
+		ranks = append(ranks, rank)
+	}
+	// but not in a random order
+	rng.Shuffle(len(ranks), func(i, j int) {
+		ranks[i], ranks[j] = ranks[j], ranks[i]
+	})
+
+	samples := make([]*KeyValueTuple[T], len(ranks))
+	for i, refNum := range tree.refsAtRanks(ranks) {
+		samples[i] = tree.keyValueTupleAt(refNum)
+	}
+	return samples
+}
+
+func (tree *Critbit[T]) keyValueTupleAt(refNum nodeIndex) *KeyValueTuple[T] {
+	return &KeyValueTuple[T]{
+		Key:   tree.refOriginalKey(refNum),
+		Value: tree.externalRefs[refNum].value,
+	}
+}
+
+// Returns the refNums of the keys with the given ranks, in the same
+// order. Without the subtree sizes, the keys are walked in order until
+// the highest rank is reached.
+func (tree *Critbit[T]) refsAtRanks(ranks []int) []nodeIndex {
+	refNums := make([]nodeIndex, len(ranks))
+	if tree.subtreeSizes != nil {
+		for i, rank := range ranks {
+			refNums[i] = tree.refAtRank(rank)
+		}
+		return refNums
+	}
+
+	positions := make(map[int]int, len(ranks))
+	maxRank := 0
+	for i, rank := range ranks {
+		positions[rank] = i
+		maxRank = max(maxRank, rank)
+	}
+	rank := 0
+	tree.walkRefs(tree.rootItemType(), tree.rootItem, func(refNum nodeIndex) bool {
+		if i, found := positions[rank]; found {
+			refNums[i] = refNum
+		}
+		rank++
+		return rank <= maxRank
+	})
+	return refNums
+}
+
+// Returns the number of keys under a child of a node
+func (tree *Critbit[T]) childSubtreeSize(node *internalNode, direction byte) int {
+	switch node.getChildType(direction) {
+	case kChildIntNode:
+		return tree.subtreeSizes[node.child[direction]]
+	case kChildExtRef:
+		return 1
+	}
+	return 0
+}
+
+// Returns the refNum of the key which has rank keys before it, in sorted
+// order. The tree must have the subtree sizes.
+func (tree *Critbit[T]) refAtRank(rank int) nodeIndex {
+	itemType := tree.rootItemType()
+	itemID := tree.rootItem
+	for itemType == kChildIntNode {
+		node := &tree.internalNodes[itemID]
+		direction := byte(kDirectionLeft)
+		if leftSize := tree.childSubtreeSize(node, kDirectionLeft); rank >= leftSize {
+			rank -= leftSize
+			direction = kDirectionRight
+		}
+		itemType = node.getChildType(direction)
+		itemID = node.child[direction]
+	}
+	return itemID
+}
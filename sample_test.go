@@ -0,0 +1,106 @@
+package critbit
+
+import (
+	"math/rand"
+	"sort"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+// Checks that the subtree sizes are right, by finding every key by rank
+func checkRanks(c *C, tree *Critbit[int]) {
+	c.Assert(tree.subtreeSizes, NotNil)
+	for rank, key := range tree.Keys() {
+		c.Check(tree.refKey(tree.refAtRank(rank)), Equals, key, Commentf("rank %d", rank))
+	}
+}
+
+func (s *MySuite) TestSubtreeSizes(c *C) {
+	rng := rand.New(rand.NewSource(40))
+	tree := New[int](0, WithSubtreeSizes())
+	for i := 0; i < 300; i++ {
+		tree.Insert(randomKey(rng), i)
+	}
+	checkRanks(c, tree)
+
+	// The sizes are kept up to date
+	for i := 0; i < 300; i++ {
+		if rng.Intn(2) == 0 {
+			tree.Insert(randomKey(rng), i)
+		} else {
+			tree.Delete(randomKey(rng))
+		}
+	}
+	checkRanks(c, tree)
+
+	left, right := tree.Split()
+	checkRanks(c, left)
+	checkRanks(c, right)
+
+	// FromLouds counts the keys under the nodes it builds
+	decoded, err := FromLouds(tree.Succinct(), WithSubtreeSizes())
+	c.Assert(err, IsNil)
+	checkRanks(c, decoded)
+}
+
+var sampleOptions = [][]Option{nil, {WithSubtreeSizes()}}
+
+func (s *MySuite) TestRandomKey(c *C) {
+	for _, options := range sampleOptions {
+		rng := rand.New(rand.NewSource(40))
+		tree := New[int](0, options...)
+		c.Check(tree.RandomKey(rng), IsNil)
+
+		keys := []string{"a", "ab", "abc", "abcd", "b", "zzzzzzzz"}
+		for i, key := range keys {
+			tree.Insert(key, i)
+		}
+		counts := make(map[string]int)
+		for i := 0; i < 6000; i++ {
+			kvt := tree.RandomKey(rng)
+			c.Assert(kvt, NotNil)
+			c.Check(kvt.Value, Equals, sort.SearchStrings(keys, kvt.Key))
+			counts[kvt.Key]++
+		}
+		// Each key is expected 1000 times; the skewed shape of the tree
+		// must not matter.
+		for _, key := range keys {
+			c.Check(counts[key] > 850 && counts[key] < 1150, Equals, true,
+				Commentf("%q was chosen %d times", key, counts[key]))
+		}
+		// Sampling doesn't change the tree
+		c.Check(tree.subtreeSizes != nil, Equals, options != nil)
+	}
+}
+
+func (s *MySuite) TestSample(c *C) {
+	for _, options := range sampleOptions {
+		rng := rand.New(rand.NewSource(40))
+		tree := New[int](0, options...)
+		c.Check(tree.Sample(3, rng), IsNil)
+		for i := 0; i < 100; i++ {
+			tree.Insert(randomKey(rng), i)
+		}
+
+		samples := tree.Sample(10, rng)
+		c.Check(samples, HasLen, 10)
+		seen := make(map[string]bool)
+		for _, kvt := range samples {
+			c.Check(seen[kvt.Key], Equals, false)
+			seen[kvt.Key] = true
+			value, found := tree.Get(kvt.Key)
+			c.Check(found, Equals, true)
+			c.Check(kvt.Value, Equals, value)
+		}
+
+		// Asking for more keys than there are returns all of them
+		var sampled []string
+		for _, kvt := range tree.Sample(tree.Length()+5, rng) {
+			sampled = append(sampled, kvt.Key)
+		}
+		sort.Strings(sampled)
+		c.Check(sampled, DeepEquals, tree.Keys())
+		c.Check(tree.subtreeSizes != nil, Equals, options != nil)
+	}
+}
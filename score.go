@@ -0,0 +1,204 @@
+package critbit
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// A ScoredKeyValueTuple is returned by TopKWithPrefix.
+type ScoredKeyValueTuple[T any] struct {
+	Key   string
+	Value T
+	Score float64
+}
+
+// A scoreIndex holds the score of each external ref, and the maximum
+// score under each internal node, in arrays which parallel the tree's
+// arrays. It is allocated when the first score is set.
+type scoreIndex struct {
+	refScores     []float64
+	nodeMaxScores []float64
+}
+
+func (scores *scoreIndex) addRef(refNum nodeIndex) {
+	if int(refNum) == len(scores.refScores) {
+		scores.refScores = append(scores.refScores, 0)
+	} else {
+		scores.refScores[refNum] = 0
+	}
+}
+
+func (scores *scoreIndex) addNode(nodeNum nodeIndex) {
+	if int(nodeNum) == len(scores.nodeMaxScores) {
+		scores.nodeMaxScores = append(scores.nodeMaxScores, 0)
+	}
+}
+
+// SetScore sets the score of a key, which TopKWithPrefix ranks keys by.
+// Keys have a score of 0 until one is set. The boolean return value
+// indicates if the key was in the tree. A score of NaN is an error, since
+// it can't be ranked; infinite scores rank above or below every finite
+// score.
+func (tree *Critbit[T]) SetScore(key string, score float64) (bool, error) {
+	if math.IsNaN(score) {
+		return false, errors.Errorf("Score of %q is NaN", key)
+	}
+	key = tree.collate(key)
+	has, refNum := tree.findRef(key, bitLen(key))
+	if !has {
+		return false, nil
+	}
+	if tree.scores == nil {
+		// Every key, and so every subtree, starts with a score of 0
+		tree.scores = &scoreIndex{
+			refScores:     make([]float64, len(tree.externalRefs)),
+			nodeMaxScores: make([]float64, len(tree.internalNodes)),
+		}
+	}
+	tree.scores.refScores[refNum] = score
+	tree.refreshAugments(key, bitLen(key))
+	return true, nil
+}
+
+// Score returns the score of a key. The boolean return value indicates
+// if the key was in the tree.
+func (tree *Critbit[T]) Score(key string) (float64, bool) {
+	key = tree.collate(key)
+	has, refNum := tree.findRef(key, bitLen(key))
+	if !has {
+		return 0, false
+	}
+	if tree.scores == nil {
+		return 0, true
+	}
+	return tree.scores.refScores[refNum], true
+}
+
+// Returns the maximum score under a child of a node
+func (tree *Critbit[T]) childMaxScore(node *internalNode, direction byte) float64 {
+	switch node.getChildType(direction) {
+	case kChildIntNode:
+		return tree.scores.nodeMaxScores[node.child[direction]]
+	case kChildExtRef:
+		return tree.scores.refScores[node.child[direction]]
+	}
+	return math.Inf(-1)
+}
+
+// Recomputes the maximum score under a node from its children
+func (tree *Critbit[T]) refreshMaxScore(nodeNum nodeIndex) {
+	node := &tree.internalNodes[nodeNum]
+	tree.scores.nodeMaxScores[nodeNum] = max(
+		tree.childMaxScore(node, kDirectionLeft),
+		tree.childMaxScore(node, kDirectionRight))
+}
+
+// TopKWithPrefix returns the k keys which start with the prefix and have
+// the highest scores, from the highest score down. Keys with the same
+// score are returned in sorted order. Since every internal node knows the
+// maximum score beneath it, the search visits the subtrees best-first,
+// and never enumerates the whole prefix subtree.
+func (tree *Critbit[T]) TopKWithPrefix(prefix string, k int) []*ScoredKeyValueTuple[T] {
+	if k <= 0 {
+		return nil
+	}
+	prefix = tree.collate(prefix)
+	itemType, itemID, found := tree.findPrefixRoot(prefix, bitLen(prefix))
+	if !found {
+		return nil
+	}
+
+	var results []*ScoredKeyValueTuple[T]
+	if tree.scores == nil {
+		// Every key has a score of 0, so the first k keys win the tie
+		tree.walkRefs(itemType, itemID, func(refNum nodeIndex) bool {
+			results = append(results, tree.scoredTuple(refNum))
+			return len(results) < k
+		})
+		return results
+	}
+
+	queue := &scoreQueue[T]{tree: tree}
+	queue.pushItem(itemType, itemID)
+	for queue.Len() > 0 && len(results) < k {
+		item := heap.Pop(queue).(scoreQueueItem)
+		if item.itemType == kChildExtRef {
+			results = append(results, tree.scoredTuple(item.itemID))
+			continue
+		}
+		node := &tree.internalNodes[item.itemID]
+		queue.pushItem(node.getChildType(kDirectionLeft), node.child[kDirectionLeft])
+		queue.pushItem(node.getChildType(kDirectionRight), node.child[kDirectionRight])
+	}
+	return results
+}
+
+func (tree *Critbit[T]) scoredTuple(refNum nodeIndex) *ScoredKeyValueTuple[T] {
+	var score float64
+	if tree.scores != nil {
+		score = tree.scores.refScores[refNum]
+	}
+	return &ScoredKeyValueTuple[T]{
+		Key:   tree.refOriginalKey(refNum),
+		Value: tree.externalRefs[refNum].value,
+		Score: score,
+	}
+}
+
+// A scoreQueue is a max-heap of the items still to be visited by
+// TopKWithPrefix, ordered by the maximum score under each item. Ties are
+// broken by the smallest key under each item, which puts them in key
+// order, as the items cover disjoint ranges of keys.
+type scoreQueue[T any] struct {
+	tree  *Critbit[T]
+	items []scoreQueueItem
+}
+
+type scoreQueueItem struct {
+	itemType byte
+	itemID   nodeIndex
+	maxScore float64
+	leftmost nodeIndex
+}
+
+func (queue *scoreQueue[T]) pushItem(itemType byte, itemID nodeIndex) {
+	tree := queue.tree
+	item := scoreQueueItem{
+		itemType: itemType,
+		itemID:   itemID,
+		leftmost: tree.leftmostRef(itemType, itemID),
+	}
+	if itemType == kChildExtRef {
+		item.maxScore = tree.scores.refScores[itemID]
+	} else {
+		item.maxScore = tree.scores.nodeMaxScores[itemID]
+	}
+	heap.Push(queue, item)
+}
+
+func (queue *scoreQueue[T]) Len() int { return len(queue.items) }
+
+func (queue *scoreQueue[T]) Less(i, j int) bool {
+	a, b := &queue.items[i], &queue.items[j]
+	if a.maxScore != b.maxScore {
+		return a.maxScore > b.maxScore
+	}
+	leftmost := queue.tree.refKey(b.leftmost)
+	return queue.tree.compareRef(a.leftmost, leftmost, queue.tree.refBits(b.leftmost, leftmost)) < 0
+}
+
+func (queue *scoreQueue[T]) Swap(i, j int) {
+	queue.items[i], queue.items[j] = queue.items[j], queue.items[i]
+}
+
+func (queue *scoreQueue[T]) Push(x any) {
+	queue.items = append(queue.items, x.(scoreQueueItem))
+}
+
+func (queue *scoreQueue[T]) Pop() any {
+	item := queue.items[len(queue.items)-1]
+	queue.items = queue.items[:len(queue.items)-1]
+	return item
+}
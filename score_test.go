@@ -0,0 +1,150 @@
+package critbit
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+// Computes the top k keys by sorting every key with the prefix
+func expectedTopK(tree *Critbit[int], prefix string, k int) []string {
+	var keys []string
+	for _, key := range tree.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		si, _ := tree.Score(keys[i])
+		sj, _ := tree.Score(keys[j])
+		return si > sj
+	})
+	if len(keys) > k {
+		keys = keys[:k]
+	}
+	return keys
+}
+
+func topKKeys(tuples []*ScoredKeyValueTuple[int]) []string {
+	var keys []string
+	for _, tuple := range tuples {
+		keys = append(keys, tuple.Key)
+	}
+	return keys
+}
+
+func (s *MySuite) TestTopKWithPrefixUnscored(c *C) {
+	tree := New[int](0)
+	for i, key := range []string{"alpha", "beta", "alps", "alp", "gamma"} {
+		tree.Insert(key, i)
+	}
+	score, has := tree.Score("beta")
+	c.Check(has, Equals, true)
+	c.Check(score, Equals, 0.0)
+	_, has = tree.Score("delta")
+	c.Check(has, Equals, false)
+
+	// With no scores, the ties are broken by key order
+	c.Check(topKKeys(tree.TopKWithPrefix("al", 2)), DeepEquals, []string{"alp", "alpha"})
+	c.Check(tree.TopKWithPrefix("x", 2), IsNil)
+	c.Check(tree.TopKWithPrefix("", 0), IsNil)
+}
+
+func (s *MySuite) TestTopKWithPrefix(c *C) {
+	tree := New[int](0)
+	for i, key := range []string{"a", "apple", "apply", "ape", "apricot", "banana", "band", "bandana"} {
+		tree.Insert(key, i)
+	}
+	for key, score := range map[string]float64{"apply": 5, "apricot": 9, "band": 7} {
+		found, err := tree.SetScore(key, score)
+		c.Assert(err, IsNil)
+		c.Check(found, Equals, true)
+	}
+	found, err := tree.SetScore("cherry", 1)
+	c.Assert(err, IsNil)
+	c.Check(found, Equals, false)
+
+	results := tree.TopKWithPrefix("", 3)
+	c.Assert(results, HasLen, 3)
+	c.Check(*results[0], Equals, ScoredKeyValueTuple[int]{"apricot", 4, 9})
+	c.Check(*results[1], Equals, ScoredKeyValueTuple[int]{"band", 6, 7})
+	c.Check(*results[2], Equals, ScoredKeyValueTuple[int]{"apply", 2, 5})
+
+	c.Check(topKKeys(tree.TopKWithPrefix("ap", 10)), DeepEquals,
+		[]string{"apricot", "apply", "ape", "apple"})
+	c.Check(topKKeys(tree.TopKWithPrefix("ban", 2)), DeepEquals, []string{"band", "banana"})
+
+	// Deleting the best key promotes the next one
+	c.Check(tree.Delete("apricot"), Equals, true)
+	c.Check(topKKeys(tree.TopKWithPrefix("a", 2)), DeepEquals, []string{"apply", "a"})
+
+	// A re-inserted key starts again with a score of 0
+	tree.Insert("apricot", 4)
+	score, _ := tree.Score("apricot")
+	c.Check(score, Equals, 0.0)
+}
+
+func (s *MySuite) TestScoreNaNAndInf(c *C) {
+	tree := New[int](0)
+	for i, key := range []string{"a", "b", "c", "d"} {
+		tree.Insert(key, i)
+	}
+	found, err := tree.SetScore("b", math.NaN())
+	c.Check(err, ErrorMatches, `Score of "b" is NaN`)
+	c.Check(found, Equals, false)
+	score, _ := tree.Score("b")
+	c.Check(score, Equals, 0.0)
+
+	for key, score := range map[string]float64{"a": math.Inf(-1), "c": math.Inf(1), "d": math.MaxFloat64} {
+		_, err := tree.SetScore(key, score)
+		c.Assert(err, IsNil)
+	}
+	c.Check(topKKeys(tree.TopKWithPrefix("", 4)), DeepEquals, []string{"c", "d", "b", "a"})
+	c.Check(tree.Validate(), IsNil)
+}
+
+func (s *MySuite) TestTopKWithPrefixRandom(c *C) {
+	rng := rand.New(rand.NewSource(35))
+	tree := New[int](0)
+	for i := 0; i < 2000; i++ {
+		key := randomKey(rng)
+		tree.Insert(key, i)
+		if rng.Intn(2) == 0 {
+			_, err := tree.SetScore(key, float64(rng.Intn(50)))
+			c.Assert(err, IsNil)
+		}
+		if rng.Intn(5) == 0 {
+			tree.Delete(randomKey(rng))
+		}
+	}
+	for _, prefix := range []string{"", "a", "b", "ab", "ca", "abc"} {
+		for _, k := range []int{1, 5, 20} {
+			c.Check(topKKeys(tree.TopKWithPrefix(prefix, k)), DeepEquals,
+				expectedTopK(tree, prefix, k), Commentf("prefix=%q k=%d", prefix, k))
+		}
+	}
+
+	// The scores follow the keys into split trees
+	left, right := tree.Split()
+	for _, half := range []*Critbit[int]{left, right} {
+		for _, key := range half.Keys() {
+			expected, _ := tree.Score(key)
+			score, _ := half.Score(key)
+			c.Check(score, Equals, expected)
+		}
+		c.Check(topKKeys(half.TopKWithPrefix("a", 10)), DeepEquals,
+			expectedTopK(half, "a", 10))
+	}
+}
+
+func randomKey(rng *rand.Rand) string {
+	key := make([]byte, 1+rng.Intn(6))
+	for i := range key {
+		key[i] = "abc"[rng.Intn(3)]
+	}
+	return string(key)
+}
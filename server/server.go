@@ -0,0 +1,284 @@
+// Package server serves a critbit tree over HTTP, with JSON requests and
+// responses, so that programs which aren't written in Go can share it.
+//
+// The endpoints are:
+//
+//	GET    /keys/{key}   the key's value, as {"key": ..., "value": ...}
+//	PUT    /keys/{key}   set the key's value to the JSON request body
+//	DELETE /keys/{key}   delete the key
+//	GET    /prefix?prefix=P&limit=N&cursor=C
+//	GET    /range?start=S&end=E&limit=N&cursor=C
+//	GET    /stats        the tree's TreeStats
+//	GET    /healthz      {"status": "ok"}
+//
+// Keys are path-escaped in the URL. Keys which aren't valid UTF-8 are
+// also written in base64, as "keyBase64". The scans write JSON lines, one
+// {"key": ..., "value": ...} object per key, in key order. If there are
+// more than limit keys, the last line is {"cursor": ...}; passing the
+// cursor to the same scan continues it after the last key written.
+// Errors are written as {"error": ...}.
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"iter"
+	"net/http"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gilramir/critbit"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPageSize is the number of keys which a scan writes, if the
+	// request has no limit.
+	DefaultPageSize = 100
+
+	// MaxPageSize is the largest limit a scan accepts.
+	MaxPageSize = 1000
+
+	// MaxValueBytes is the largest request body a PUT accepts.
+	MaxValueBytes = 1 << 20
+)
+
+// A Server serves a tree over HTTP. A read-write lock protects the tree,
+// so that any number of lookups and scans can run at once, but updates
+// run alone.
+type Server[T any] struct {
+	mu   sync.RWMutex
+	tree *critbit.Critbit[T]
+	mux  *http.ServeMux
+}
+
+// New returns a Server for the tree. After this, the tree must only be
+// used through the Server, or through View and Update.
+func New[T any](tree *critbit.Critbit[T]) *Server[T] {
+	s := &Server[T]{tree: tree, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /keys/{key...}", s.handleGet)
+	s.mux.HandleFunc("PUT /keys/{key...}", s.handlePut)
+	s.mux.HandleFunc("DELETE /keys/{key...}", s.handleDelete)
+	s.mux.HandleFunc("GET /prefix", s.handlePrefix)
+	s.mux.HandleFunc("GET /range", s.handleRange)
+	s.mux.HandleFunc("GET /stats", s.handleStats)
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// View calls fn with the tree, holding the read lock; fn must not change
+// the tree.
+func (s *Server[T]) View(fn func(tree *critbit.Critbit[T])) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.tree)
+}
+
+// Update calls fn with the tree, holding the write lock.
+func (s *Server[T]) Update(fn func(tree *critbit.Critbit[T])) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.tree)
+}
+
+// An item is a key and its value, as the server writes them. JSON
+// strings can't hold keys which aren't valid UTF-8, so they are also
+// given in base64.
+type item[T any] struct {
+	Key       string `json:"key"`
+	KeyBase64 string `json:"keyBase64,omitempty"`
+	Value     T      `json:"value"`
+}
+
+func newItem[T any](key string, value T) item[T] {
+	it := item[T]{Key: key, Value: value}
+	if !utf8.ValidString(key) {
+		it.KeyBase64 = base64.StdEncoding.EncodeToString([]byte(key))
+	}
+	return it
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// The client may have gone; there's nobody to tell
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (s *Server[T]) handleGet(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	var value T
+	var found bool
+	s.View(func(tree *critbit.Critbit[T]) {
+		value, found = tree.Get(key)
+	})
+	if !found {
+		writeError(w, http.StatusNotFound, errors.Errorf("Key %q not found", key))
+		return
+	}
+	writeJSON(w, http.StatusOK, newItem(key, value))
+}
+
+func (s *Server[T]) handlePut(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxValueBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, errors.Wrap(err, "Reading the value"))
+		return
+	}
+	var value T
+	if err := json.Unmarshal(body, &value); err != nil {
+		writeError(w, http.StatusBadRequest, errors.Wrap(err, "Decoding the value"))
+		return
+	}
+
+	var inserted bool
+	s.Update(func(tree *critbit.Critbit[T]) {
+		inserted, err = tree.Insert(key, value)
+		if err == nil && !inserted {
+			tree.Update(key, value)
+		}
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	status := http.StatusOK
+	if inserted {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, newItem(key, value))
+}
+
+func (s *Server[T]) handleDelete(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	var found bool
+	s.Update(func(tree *critbit.Critbit[T]) {
+		found = tree.Delete(key)
+	})
+	if !found {
+		writeError(w, http.StatusNotFound, errors.Errorf("Key %q not found", key))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server[T]) handleStats(w http.ResponseWriter, r *http.Request) {
+	var stats critbit.TreeStats
+	s.View(func(tree *critbit.Critbit[T]) {
+		stats = tree.Stats()
+	})
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server[T]) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server[T]) handlePrefix(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	s.scan(w, r,
+		func(tree *critbit.Critbit[T]) iter.Seq2[string, T] {
+			return tree.IteratePrefix(prefix)
+		},
+		func(tree *critbit.Critbit[T], lastKey string) iter.Seq2[string, T] {
+			return tree.IteratePrefixAfter(prefix, lastKey)
+		})
+}
+
+func (s *Server[T]) handleRange(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	start, end := query.Get("start"), query.Get("end")
+	s.scan(w, r,
+		func(tree *critbit.Critbit[T]) iter.Seq2[string, T] {
+			return tree.IterateRange(start, end)
+		},
+		func(tree *critbit.Critbit[T], lastKey string) iter.Seq2[string, T] {
+			return tree.IterateAfter(lastKey, end)
+		})
+}
+
+// A cursor is the last key which a page held, base64url-encoded. The
+// next page starts after it, in the tree's order, so that the pages
+// follow the tree's collation, if it has one.
+func encodeCursor(lastKey string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	lastKey, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", errors.Errorf("Invalid cursor %q", cursor)
+	}
+	return string(lastKey), nil
+}
+
+func parseLimit(limit string) (int, error) {
+	if limit == "" {
+		return DefaultPageSize, nil
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 || n > MaxPageSize {
+		return 0, errors.Errorf("Limit must be from 1 to %d", MaxPageSize)
+	}
+	return n, nil
+}
+
+// Writes a page of a scan, as JSON lines. The first page is read from
+// first, and the pages after it from resume, which is given the cursor's
+// key. The page is copied while the read lock is held, and written after
+// it is released, so that slow clients don't hold up updates.
+func (s *Server[T]) scan(w http.ResponseWriter, r *http.Request,
+	first func(*critbit.Critbit[T]) iter.Seq2[string, T],
+	resume func(*critbit.Critbit[T], string) iter.Seq2[string, T]) {
+	query := r.URL.Query()
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	pages := first
+	if cursor := query.Get("cursor"); cursor != "" {
+		lastKey, err := decodeCursor(cursor)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		pages = func(tree *critbit.Critbit[T]) iter.Seq2[string, T] {
+			return resume(tree, lastKey)
+		}
+	}
+
+	// One more key than the limit is read, to tell whether there are more
+	page := make([]item[T], 0, min(limit+1, DefaultPageSize))
+	s.View(func(tree *critbit.Critbit[T]) {
+		for key, value := range pages(tree) {
+			page = append(page, newItem(key, value))
+			if len(page) > limit {
+				break
+			}
+		}
+	})
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	for i := range page[:min(len(page), limit)] {
+		if err := encoder.Encode(&page[i]); err != nil {
+			return
+		}
+	}
+	if len(page) > limit {
+		_ = encoder.Encode(map[string]string{"cursor": encodeCursor(page[limit-1].Key)})
+	}
+}
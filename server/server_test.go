@@ -0,0 +1,246 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gilramir/critbit"
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func newTestServer(c *C, keys ...string) (*httptest.Server, *Server[int]) {
+	tree := critbit.New[int](0)
+	for i, key := range keys {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+	}
+	s := New(tree)
+	ts := httptest.NewServer(s)
+	return ts, s
+}
+
+func doRequest(c *C, method string, url string, body string) (int, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	c.Assert(err, IsNil)
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	return resp.StatusCode, string(data)
+}
+
+func keyURL(ts *httptest.Server, key string) string {
+	return ts.URL + "/keys/" + url.PathEscape(key)
+}
+
+func (s *MySuite) TestKeys(c *C) {
+	ts, _ := newTestServer(c, "apple", "banana")
+	defer ts.Close()
+
+	status, body := doRequest(c, "GET", keyURL(ts, "apple"), "")
+	c.Check(status, Equals, http.StatusOK)
+	c.Check(body, Equals, `{"key":"apple","value":0}`+"\n")
+
+	status, body = doRequest(c, "GET", keyURL(ts, "cherry"), "")
+	c.Check(status, Equals, http.StatusNotFound)
+	c.Check(body, Equals, `{"error":"Key \"cherry\" not found"}`+"\n")
+
+	// Keys can hold slashes, spaces and NULs, and can be empty
+	for i, key := range []string{"a/b c", "nul\x00", ""} {
+		status, _ = doRequest(c, "PUT", keyURL(ts, key), fmt.Sprint(10+i))
+		c.Check(status, Equals, http.StatusCreated, Commentf("%q", key))
+		status, body = doRequest(c, "GET", keyURL(ts, key), "")
+		c.Check(status, Equals, http.StatusOK, Commentf("%q", key))
+		var got item[int]
+		c.Assert(json.Unmarshal([]byte(body), &got), IsNil)
+		c.Check(got, Equals, newItem(key, 10+i))
+	}
+
+	status, body = doRequest(c, "PUT", keyURL(ts, "apple"), "42")
+	c.Check(status, Equals, http.StatusOK)
+	c.Check(body, Equals, `{"key":"apple","value":42}`+"\n")
+	status, body = doRequest(c, "PUT", keyURL(ts, "apple"), `"not an int"`)
+	c.Check(status, Equals, http.StatusBadRequest)
+	c.Check(body, Matches, `\{"error":"Decoding the value: .*"\}`+"\n")
+	status, _ = doRequest(c, "PUT", keyURL(ts, "big"), strings.Repeat(" ", MaxValueBytes+1)+"1")
+	c.Check(status, Equals, http.StatusRequestEntityTooLarge)
+
+	status, body = doRequest(c, "DELETE", keyURL(ts, "apple"), "")
+	c.Check(status, Equals, http.StatusNoContent)
+	c.Check(body, Equals, "")
+	status, _ = doRequest(c, "DELETE", keyURL(ts, "apple"), "")
+	c.Check(status, Equals, http.StatusNotFound)
+	status, _ = doRequest(c, "GET", keyURL(ts, "apple"), "")
+	c.Check(status, Equals, http.StatusNotFound)
+
+	status, _ = doRequest(c, "POST", keyURL(ts, "apple"), "1")
+	c.Check(status, Equals, http.StatusMethodNotAllowed)
+}
+
+// Reads the lines of a scan, and returns the keys and the cursor
+func readScan(c *C, ts *httptest.Server, path string, query url.Values) ([]string, string) {
+	resp, err := http.Get(ts.URL + path + "?" + query.Encode())
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Check(resp.Header.Get("Content-Type"), Equals, "application/x-ndjson")
+
+	var keys []string
+	var cursor string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line struct {
+			Key       *string
+			KeyBase64 []byte
+			Value     int
+			Cursor    string
+		}
+		c.Assert(json.Unmarshal(scanner.Bytes(), &line), IsNil)
+		c.Check(cursor, Equals, "", Commentf("A line follows the cursor"))
+		if line.KeyBase64 != nil {
+			keys = append(keys, string(line.KeyBase64))
+		} else if line.Key != nil {
+			keys = append(keys, *line.Key)
+		} else {
+			cursor = line.Cursor
+		}
+	}
+	c.Assert(scanner.Err(), IsNil)
+	return keys, cursor
+}
+
+// Reads all the pages of a scan
+func readAllPages(c *C, ts *httptest.Server, path string, query url.Values) ([]string, int) {
+	var keys []string
+	pages := 0
+	for {
+		page, cursor := readScan(c, ts, path, query)
+		keys = append(keys, page...)
+		pages++
+		if cursor == "" {
+			return keys, pages
+		}
+		query.Set("cursor", cursor)
+	}
+}
+
+func (s *MySuite) TestScans(c *C) {
+	var keys []string
+	for i := 0; i < 25; i++ {
+		keys = append(keys, fmt.Sprintf("k%02d", i))
+	}
+	ts, server := newTestServer(c, append(keys, "a", "k", "k\xff", "l")...)
+	defer ts.Close()
+
+	got, pages := readAllPages(c, ts, "/prefix", url.Values{"prefix": {"k"}, "limit": {"10"}})
+	c.Check(got, DeepEquals, append(append([]string{"k"}, keys...), "k\xff"))
+	c.Check(pages, Equals, 3)
+
+	got, pages = readAllPages(c, ts, "/prefix", url.Values{"prefix": {"k1"}})
+	c.Check(got, DeepEquals, keys[10:20])
+	c.Check(pages, Equals, 1)
+
+	got, _ = readAllPages(c, ts, "/range", url.Values{"start": {"k05"}, "end": {"k08"}, "limit": {"1"}})
+	c.Check(got, DeepEquals, keys[5:8])
+
+	got, _ = readAllPages(c, ts, "/range", url.Values{"start": {"k2"}, "limit": {"4"}})
+	c.Check(got, DeepEquals, append(append([]string{}, keys[20:]...), "k\xff", "l"))
+
+	got, pages = readAllPages(c, ts, "/range", url.Values{})
+	c.Check(got, HasLen, 29)
+	c.Check(pages, Equals, 1)
+
+	// A page which ends at the last key has no cursor
+	got, cursor := readScan(c, ts, "/prefix", url.Values{"prefix": {"k1"}, "limit": {"10"}})
+	c.Check(got, HasLen, 10)
+	c.Check(cursor, Equals, "")
+
+	// Keys inserted after the cursor are seen by the next page
+	page, cursor := readScan(c, ts, "/range", url.Values{"start": {"k"}, "end": {"l"}, "limit": {"2"}})
+	c.Check(page, DeepEquals, []string{"k", "k00"})
+	server.Update(func(tree *critbit.Critbit[int]) {
+		tree.Delete("k01")
+		_, err := tree.Insert("k000", 100)
+		c.Assert(err, IsNil)
+	})
+	page, _ = readScan(c, ts, "/range", url.Values{"start": {"k"}, "end": {"l"}, "limit": {"2"}, "cursor": {cursor}})
+	c.Check(page, DeepEquals, []string{"k000", "k02"})
+
+	for _, query := range []string{"limit=0", "limit=1001", "limit=x", "cursor=!!"} {
+		status, body := doRequest(c, "GET", ts.URL+"/range?"+query, "")
+		c.Check(status, Equals, http.StatusBadRequest, Commentf(query))
+		c.Check(body, Matches, `\{"error":".*"\}`+"\n")
+	}
+}
+
+// The pages follow the tree's collation, even though the keys in the
+// cursors aren't collated
+func (s *MySuite) TestScansCollated(c *C) {
+	tree := critbit.New[int](0, critbit.WithCollation(critbit.FoldASCII))
+	for i, key := range []string{"Apple", "Apricot", "avocado", "Banana", "cherry"} {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+	}
+	ts := httptest.NewServer(New(tree))
+	defer ts.Close()
+
+	got, pages := readAllPages(c, ts, "/prefix", url.Values{"prefix": {"a"}, "limit": {"1"}})
+	c.Check(got, DeepEquals, []string{"Apple", "Apricot", "avocado"})
+	c.Check(pages, Equals, 3)
+
+	got, pages = readAllPages(c, ts, "/range", url.Values{"start": {"apricot"}, "end": {"C"}, "limit": {"1"}})
+	c.Check(got, DeepEquals, []string{"Apricot", "avocado", "Banana"})
+	c.Check(pages, Equals, 3)
+}
+
+func (s *MySuite) TestHealthAndStats(c *C) {
+	ts, _ := newTestServer(c, "a", "b", "c")
+	defer ts.Close()
+
+	status, body := doRequest(c, "GET", ts.URL+"/healthz", "")
+	c.Check(status, Equals, http.StatusOK)
+	c.Check(body, Equals, `{"status":"ok"}`+"\n")
+
+	status, body = doRequest(c, "GET", ts.URL+"/stats", "")
+	c.Check(status, Equals, http.StatusOK)
+	var stats critbit.TreeStats
+	c.Assert(json.Unmarshal([]byte(body), &stats), IsNil)
+	c.Check(stats.NumKeys, Equals, 3)
+	c.Check(stats.LiveInternalNodes, Equals, 2)
+}
+
+// Concurrent requests are safe; run with -race to check
+func (s *MySuite) TestConcurrent(c *C) {
+	ts, server := newTestServer(c)
+	defer ts.Close()
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				key := fmt.Sprintf("w%d-%d", worker, i)
+				doRequest(c, "PUT", keyURL(ts, key), fmt.Sprint(i))
+				doRequest(c, "GET", ts.URL+"/prefix?prefix=w", "")
+				if i%2 == 0 {
+					doRequest(c, "DELETE", keyURL(ts, key), "")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	server.View(func(tree *critbit.Critbit[int]) {
+		c.Check(tree.Length(), Equals, 100)
+		c.Check(tree.Validate(), IsNil)
+	})
+}
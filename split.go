@@ -1,10 +1,5 @@
 package critbit
 
-import (
-	"fmt"
-	"sync"
-)
-
 // Split splits a tree into two trees, each having one half of the key-value pairs.
 // If there is an odd number of keys, the right tree (the second returned tree)
 // will have the extra key-value pair.
@@ -12,351 +7,156 @@ func (tree *Critbit[T]) Split() (*Critbit[T], *Critbit[T]) {
 	// Empty, or other trivial cases?
 	switch tree.numExternalRefs {
 	case 0:
-		return tree, New[T](0)
+		return tree, tree.newLike(0)
 	case 1:
-		return tree, New[T](0)
-	case 2:
-		return tree.splitTwoExternalRefs()
+		return tree, tree.newLike(0)
 	}
 
 	leftNumKeys := tree.numExternalRefs / 2
 	return tree.SplitAt(leftNumKeys)
 }
 
-func (tree *Critbit[T]) splitTwoExternalRefs() (*Critbit[T], *Critbit[T]) {
-	rootNode := &tree.internalNodes[tree.rootItem]
-
-	left := New[T](1)
-	leftRef := &tree.externalRefs[rootNode.child[0]]
-	leftRefNum, err := tree.addExternalRef(leftRef.key, leftRef.value)
-	// An error should not happen because of the size of the tree
-	if err != nil {
-		panic(err.Error())
-	}
-	left.rootItem = leftRefNum
-
-	right := New[T](1)
-	rightRef := &tree.externalRefs[rootNode.child[1]]
-	rightRefNum, err := tree.addExternalRef(rightRef.key, rightRef.value)
-	// An error should not happen because of the size of the tree
-	if err != nil {
-		panic(err.Error())
-	}
-	right.rootItem = rightRefNum
-	return left, right
-}
-
 // Split splits a tree into two arbitrarily sized trees. The leftNumKeys
 // arguments indicates how many treees the left tree (the first returned tree)
 // should have. The right tree (the second returned tree) will have the rest.
+// The original tree is not changed.
 func (tree *Critbit[T]) SplitAt(leftNumKeys int) (*Critbit[T], *Critbit[T]) {
-	leftItemChan := make(chan *splitItem[T])
-	rightItemChan := make(chan *splitItem[T])
-
+	leftNumKeys = max(0, min(leftNumKeys, tree.numExternalRefs))
 	rightNumKeys := tree.numExternalRefs - leftNumKeys
-	if rightNumKeys < 0 {
-		rightNumKeys = 0
-	}
-	leftTree := New[T](leftNumKeys)
-	rightTree := New[T](rightNumKeys)
-
-	go tree.splitWalkTree(leftNumKeys, leftItemChan, rightItemChan)
-
-	var wg sync.WaitGroup
-	wg.Add(2)
-	go createLeftSplit(&wg, leftTree, leftItemChan)
-	go createRightSplit(&wg, rightTree, rightItemChan)
-	wg.Wait()
-
-	return leftTree, rightTree
-}
-
-func (tree *Critbit[T]) splitWalkTree(leftNumKeys int,
-	leftItemChan chan *splitItem[T], rightItemChan chan *splitItem[T]) {
 
-	defer close(leftItemChan)
-	defer close(rightItemChan)
-
-	state := &splitWalkerState[T]{
-		// It's impossible to approximate the longest path in the tree,
-		// but we can use the # of external refs as a pseuco max
-		path:                 make([]*splitItem[T], 0, tree.numExternalRefs),
-		numLeftKeysRemaining: leftNumKeys,
-		channels:             make([]chan *splitItem[T], 2),
-		feedingRight:         leftNumKeys == 0,
+	split := &splitter[T]{
+		tree:  tree,
+		sizes: make([]int, len(tree.internalNodes)),
 	}
+	split.countKeys(tree.rootItemType(), tree.rootItem)
 
-	state.channels[0] = leftItemChan
-	state.channels[1] = rightItemChan
-
-	tree.splitWalkTreeRecurse(state)
-}
-
-type splitWalkerState[T any] struct {
-	visitedRoot          bool
-	path                 []*splitItem[T]
-	numLeftKeysRemaining int
-	channels             []chan *splitItem[T]
-	feedingRight         bool
-	channel              chan *splitItem[T]
-}
-
-func (tree *Critbit[T]) splitWalkTreeRecurse(state *splitWalkerState[T]) {
-	sendPopup := true
-	if state.feedingRight {
-		state.channel = state.channels[1]
-	} else {
-		state.channel = state.channels[0]
+	leftTree := split.newTree(leftNumKeys)
+	if leftNumKeys > 0 {
+		leftTree.rootItem = split.copyFirst(leftTree, tree.rootItemType(), tree.rootItem, leftNumKeys)
 	}
-
-	// Just started?
-	if !state.visitedRoot {
-		state.path = append(state.path, &splitItem[T]{
-			metaType: kSplitItemTreeData,
-			itemType: kChildIntNode,
-			itemID:   tree.rootItem,
-			offset:   tree.internalNodes[tree.rootItem].offset,
-			bit:      tree.internalNodes[tree.rootItem].bit,
-		})
-		state.visitedRoot = true
+	rightTree := split.newTree(rightNumKeys)
+	if rightNumKeys > 0 {
+		rightTree.rootItem = split.copyLast(rightTree, tree.rootItemType(), tree.rootItem, rightNumKeys)
 	}
 
-	item := state.path[len(state.path)-1]
-	state.channel <- item
-
-	switch item.itemType {
-	case kChildIntNode:
-		state.path = append(state.path, tree.createSplitItemFromNodeChild(item.itemID, 0))
-		tree.splitWalkTreeRecurse(state)
-		state.path = state.path[:len(state.path)-1]
-
-		state.path = append(state.path, tree.createSplitItemFromNodeChild(item.itemID, 1))
-		tree.splitWalkTreeRecurse(state)
-		state.path = state.path[:len(state.path)-1]
+	// The subtrees changed shape, so their summaries did too
+	leftTree.refreshAllAugments(leftTree.rootItemType(), leftTree.rootItem)
+	rightTree.refreshAllAugments(rightTree.rootItemType(), rightTree.rootItem)
+	return leftTree, rightTree
+}
 
-	case kChildExtRef:
-		if !state.feedingRight {
-			state.numLeftKeysRemaining--
-			if state.numLeftKeysRemaining == 0 {
-				state.feedingRight = true
-				state.channel = state.channels[1]
-				// Need to feed 'path' up to, but not including, the ext ref to the right tree,
-				// so popups make sense.
-				// But we need to make copies of each splitItem, as the leftTree will need
-				// its own copy
+// A splitter copies the keys on one side of a split into a new tree.
+// In a critbit tree, the keys under a node agree on every bit before the
+// node's critical bit, so a node with keys left on both of its sides
+// still separates them at the same bit, and a node with keys on only one
+// side is no longer needed, and is elided.
+type splitter[T any] struct {
+	tree  *Critbit[T]
+	sizes []int // the number of keys under each internal node
+}
 
-				for _, pathItem := range state.path[0 : len(state.path)-1] {
-					clonedItem := pathItem.Clone()
-					state.channel <- clonedItem
-				}
-				sendPopup = false
-			}
-		}
-	}
-	// The left reader gets popups only until the # keys hasn't been reached;
-	if sendPopup && (state.feedingRight || state.numLeftKeysRemaining > 0) {
-		popupItem := splitItem[T]{metaType: kSplitItemPopUp}
-		state.channel <- &popupItem
+func (split *splitter[T]) newTree(capacityStrings int) *Critbit[T] {
+	newTree := split.tree.newLike(capacityStrings)
+	if split.tree.scores != nil {
+		newTree.scores = &scoreIndex{}
 	}
+	return newTree
 }
 
-func (tree *Critbit[T]) createSplitItemFromNodeChild(nodeNum uint32, childDirection byte) *splitItem[T] {
-	node := &tree.internalNodes[nodeNum]
-	itemType := node.getChildType(childDirection)
+func (split *splitter[T]) countKeys(itemType byte, itemID nodeIndex) int {
 	switch itemType {
-	case kChildIntNode:
-		return &splitItem[T]{
-			metaType:  kSplitItemTreeData,
-			itemType:  kChildIntNode,
-			itemID:    node.child[childDirection],
-			direction: childDirection,
-			offset:    node.offset,
-			bit:       node.bit,
-		}
 	case kChildExtRef:
-		itemID := node.child[childDirection]
-		key := tree.externalRefs[itemID].key
-		value := tree.externalRefs[itemID].value
-		return &splitItem[T]{
-			metaType:  kSplitItemTreeData,
-			itemType:  kChildExtRef,
-			itemID:    itemID,
-			direction: childDirection,
-			key:       key,
-			value:     value,
-		}
-	default:
-		panic(fmt.Sprintf("Node %d has unexpected child type %d in direction %d",
-			nodeNum, node.getChildType(childDirection), childDirection))
+		return 1
+	case kChildIntNode:
+		node := &split.tree.internalNodes[itemID]
+		split.sizes[itemID] = split.countKeys(node.getChildType(kDirectionLeft), node.child[kDirectionLeft]) +
+			split.countKeys(node.getChildType(kDirectionRight), node.child[kDirectionRight])
+		return split.sizes[itemID]
 	}
+	return 0
 }
 
-const (
-	kSplitItemTreeData = 1
-	kSplitItemPopUp    = 2
-)
-
-type splitItem[T any] struct {
-	metaType  int
-	newTreeID uint32
-
-	itemType  uint8
-	itemID    uint32
-	direction byte
-
-	// If internalNode
-	offset uint16
-	bit    uint8
-	// If externalRef
-	key   string
-	value T
-}
-
-// Clones, but clears newTreeID
-func (item *splitItem[T]) Clone() *splitItem[T] {
-	return &splitItem[T]{
-		metaType:  item.metaType,
-		itemType:  item.itemType,
-		itemID:    item.itemID,
-		direction: item.direction,
-		offset:    item.offset,
-		bit:       item.bit,
-		key:       item.key,
-		value:     item.value,
+func (split *splitter[T]) size(itemType byte, itemID nodeIndex) int {
+	if itemType == kChildExtRef {
+		return 1
 	}
+	return split.sizes[itemID]
 }
 
-func createLeftSplit[T any](wg *sync.WaitGroup, tree *Critbit[T], itemChan chan *splitItem[T]) {
-	defer wg.Done()
-
-	// Populate the tree
-	_ = tree.populateFromSplitChannel("left", itemChan)
-
-	// Elide the root and sides
-	tree.postSplitElideRootIfNeeded(1)
-	tree.postSplitZipSide(1)
-}
-
-func createRightSplit[T any](wg *sync.WaitGroup, tree *Critbit[T], itemChan chan *splitItem[T]) {
-	defer wg.Done()
-
-	// Populate the tree
-	_ = tree.populateFromSplitChannel("right", itemChan)
-
-	// Elide the root and sides
-	tree.postSplitElideRootIfNeeded(0)
-	tree.postSplitZipSide(0)
+// Copies the first n keys under an item, where 0 < n <= the item's size,
+// and returns the ID of the copy, whose type is copiedType(n).
+func (split *splitter[T]) copyFirst(dst *Critbit[T], itemType byte, itemID nodeIndex, n int) nodeIndex {
+	if n == split.size(itemType, itemID) {
+		return split.copyItem(dst, itemType, itemID)
+	}
+	node := &split.tree.internalNodes[itemID]
+	leftType, leftID := node.getChildType(kDirectionLeft), node.child[kDirectionLeft]
+	rightType, rightID := node.getChildType(kDirectionRight), node.child[kDirectionRight]
+	leftSize := split.size(leftType, leftID)
+	if n <= leftSize {
+		return split.copyFirst(dst, leftType, leftID, n)
+	}
+	// Both sides keep keys
+	newLeftID := split.copyItem(dst, leftType, leftID)
+	newRightID := split.copyFirst(dst, rightType, rightID, n-leftSize)
+	return split.copyNode(dst, node, leftType, newLeftID, copiedType(n-leftSize), newRightID)
 }
 
-func (tree *Critbit[T]) populateFromSplitChannel(side string, itemChan chan *splitItem[T]) []*splitItem[T] {
-	var path []*splitItem[T]
-	for item := range itemChan {
-		if item.metaType == kSplitItemPopUp {
-			path = path[:len(path)-1]
-			continue
-		}
-
-		switch item.itemType {
-		case kChildIntNode:
-			nodeNum, node := tree.addInternalNode()
-			item.newTreeID = nodeNum
-			node.offset = item.offset
-			node.bit = item.bit
-		case kChildExtRef:
-			refNum, err := tree.addExternalRef(item.key, item.value)
-			// An error should not happen because of the size of the tree
-			if err != nil {
-				panic(err.Error())
-			}
-			item.newTreeID = refNum
-		}
-
-		if len(path) == 0 {
-			if item.itemType != kChildIntNode {
-				panic(fmt.Sprintf("(%s) First node has type %d", side, item.itemType))
-			}
-			tree.rootItem = item.newTreeID
-		} else {
-			parentNode := &tree.internalNodes[path[len(path)-1].newTreeID]
-			parentNode.setChild(item.direction, item.newTreeID, item.itemType)
-		}
-		path = append(path, item)
+// Copies the last n keys under an item, like copyFirst
+func (split *splitter[T]) copyLast(dst *Critbit[T], itemType byte, itemID nodeIndex, n int) nodeIndex {
+	if n == split.size(itemType, itemID) {
+		return split.copyItem(dst, itemType, itemID)
 	}
-	return path
+	node := &split.tree.internalNodes[itemID]
+	leftType, leftID := node.getChildType(kDirectionLeft), node.child[kDirectionLeft]
+	rightType, rightID := node.getChildType(kDirectionRight), node.child[kDirectionRight]
+	rightSize := split.size(rightType, rightID)
+	if n <= rightSize {
+		return split.copyLast(dst, rightType, rightID, n)
+	}
+	// Both sides keep keys
+	newLeftID := split.copyLast(dst, leftType, leftID, n-rightSize)
+	newRightID := split.copyItem(dst, rightType, rightID)
+	return split.copyNode(dst, node, copiedType(n-rightSize), newLeftID, rightType, newRightID)
 }
 
-func (tree *Critbit[T]) postSplitElideRootIfNeeded(direction byte) {
-	// Walk down from the root, eliding the root as necessary.
-	if tree.rootItemType() == kChildIntNode {
-		rootNode := &tree.internalNodes[tree.rootItem]
-		for rootNode.getChildType(direction) == kChildNil {
-			prevRootItem := tree.rootItem
-			tree.rootItem = tree.internalNodes[prevRootItem].child[1-direction]
-			tree.deleteInternalNode(prevRootItem)
-			rootNode = &tree.internalNodes[tree.rootItem]
-		}
+// Returns the type of a copy of n keys
+func copiedType(n int) byte {
+	if n == 1 {
+		return kChildExtRef
 	}
+	return kChildIntNode
 }
 
-func (tree *Critbit[T]) postSplitZipSide(direction byte) {
-	// Now that we know we don't need to elide the root, walk down
-	// from the root, towards the right, eliding as necessary
-	if tree.rootItemType() != kChildIntNode {
-		return
-	}
-	var prevNodeNum uint32 = kNilNode
-	var prevNode *internalNode
-	var nodeNum uint32 = tree.rootItem
-	var node *internalNode
-	pathNodeNums := make([]uint32, 0)
+func (split *splitter[T]) copyNode(dst *Critbit[T], node *internalNode,
+	leftType byte, leftID nodeIndex, rightType byte, rightID nodeIndex) nodeIndex {
+	newNodeNum, newNode := dst.addInternalNode()
+	newNode.offset = node.offset
+	newNode.bit = node.bit
+	newNode.setChild(kDirectionLeft, leftID, leftType)
+	newNode.setChild(kDirectionRight, rightID, rightType)
+	return newNodeNum
+}
 
-	for keepGoing := true; keepGoing; {
-		node = &tree.internalNodes[nodeNum]
-		// Do something based on the right child type
-		switch node.getChildType(direction) {
-		case kChildExtRef:
-			keepGoing = false
-			break
-		case kChildIntNode:
-			pathNodeNums = append(pathNodeNums, nodeNum)
-			prevNodeNum = nodeNum
-			prevNode = node
-			nodeNum = node.child[direction]
-			continue
-		case kChildNil:
-			lchildType := node.getChildType(1 - direction)
-			if lchildType == kChildNil {
-				// The node is elided, but really the prevNode needs to be elided as this node
-				// is _completely unnecessary
-				tree.deleteInternalNode(nodeNum)
-				prevNode.setChild(direction, nodeNum, kChildNil)
-				// Go up
-				nodeNum = prevNodeNum
-				node = prevNode
-				if len(pathNodeNums) > 1 {
-					prevNodeNum = pathNodeNums[len(pathNodeNums)-2]
-					prevNode = &tree.internalNodes[prevNodeNum]
-					continue
-				} else {
-					tree.rootItem = prevNode.child[1-direction]
-					tree.deleteInternalNode(tree.rootItem)
-					keepGoing = false
-					break
-				}
-			}
-			lchildID := node.child[1-direction]
-			prevNode.setChild(direction, lchildID, lchildType)
-			tree.deleteInternalNode(nodeNum)
-			// Do something based on the left child type
-			switch lchildType {
-			case kChildExtRef:
-				keepGoing = false
-				break
-			case kChildIntNode:
-				// prevNode stays, but node changes because we just elided ourselves!
-				nodeNum = lchildID
-				continue
-			}
+// Copies an item and everything under it
+func (split *splitter[T]) copyItem(dst *Critbit[T], itemType byte, itemID nodeIndex) nodeIndex {
+	src := split.tree
+	if itemType == kChildExtRef {
+		key := src.refKey(itemID)
+		refNum, err := dst.addExternalRef(key, src.refBits(itemID, key), src.refOriginalKey(itemID),
+			src.externalRefs[itemID].value)
+		// An error should not happen because of the size of the tree
+		if err != nil {
+			panic(err.Error())
+		}
+		if src.scores != nil {
+			dst.scores.refScores[refNum] = src.scores.refScores[itemID]
 		}
+		return refNum
 	}
+	node := &src.internalNodes[itemID]
+	leftType, rightType := node.getChildType(kDirectionLeft), node.getChildType(kDirectionRight)
+	newLeftID := split.copyItem(dst, leftType, node.child[kDirectionLeft])
+	newRightID := split.copyItem(dst, rightType, node.child[kDirectionRight])
+	return split.copyNode(dst, node, leftType, newLeftID, rightType, newRightID)
 }
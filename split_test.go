@@ -13,7 +13,9 @@ func (s *MySuite) testSplit(c *C, tree *Critbit[int64], table []string, name str
 	// Assume the keys are 0-n, and assume that the keys are in alphabetical order!
 	numKeys := len(table)
 	for splitAt := 0; splitAt < numKeys; splitAt++ {
-		leftSplit, _ := tree.SplitAt(splitAt)
+		leftSplit, rightSplit := tree.SplitAt(splitAt)
+		c.Check(leftSplit.Validate(), IsNil, Commentf("%s left split at %d", name, splitAt))
+		c.Check(rightSplit.Validate(), IsNil, Commentf("%s right split at %d", name, splitAt))
 
 		// Make the natural versions of the trees
 		leftNatural := New[int64](numKeys)
@@ -31,8 +33,19 @@ func (s *MySuite) testSplit(c *C, tree *Critbit[int64], table []string, name str
 
 		leftSame := compareLouds(leftSplit, leftNatural, name, "left", splitAt)
 		c.Check(leftSame, Equals, true)
-		rightSame := compareLouds(leftSplit, leftNatural, name, "left", splitAt)
+		rightSame := compareLouds(rightSplit, rightNatural, name, "right", splitAt)
 		c.Check(rightSame, Equals, true)
+
+		// Every key must be found in the tree it was split into
+		for i = 0; i < int64(numKeys); i++ {
+			split := leftSplit
+			if i >= int64(splitAt) {
+				split = rightSplit
+			}
+			value, found := split.Get(table[i])
+			c.Check(found, Equals, true, Commentf("%s split at %d: %q", name, splitAt, table[i]))
+			c.Check(value, Equals, i)
+		}
 	}
 }
 
@@ -176,3 +189,33 @@ func (s *MySuite) TestSplit3(c *C) {
 
 	s.testSplit(c, tree, table, "split3")
 }
+
+// A subtree which goes whole into a split tree must keep the critical
+// bits of its own nodes, not those of its parent. The LOUDS comparison in
+// testSplit only checks the shape, so this compares the offsets and bits
+// with those of trees built from the same keys.
+func (s *MySuite) TestSplitKeepsCriticalBits(c *C) {
+	// Each level of nesting tests a later byte than its parent
+	table := []string{"a", "ab", "abc", "abcd", "abce", "abd", "ac", "b", "bcdefg", "bcdefh"}
+	tree := New[int](0)
+	for i, key := range table {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+	}
+	for splitAt := 0; splitAt <= len(table); splitAt++ {
+		left, right := tree.SplitAt(splitAt)
+		for _, half := range []struct {
+			split *Critbit[int]
+			keys  []string
+		}{{left, table[:splitAt]}, {right, table[splitAt:]}} {
+			natural := New[int](0)
+			for _, key := range half.keys {
+				_, err := natural.Insert(key, 0)
+				c.Assert(err, IsNil)
+			}
+			got, want := half.split.Succinct(), natural.Succinct()
+			c.Check(got.Offsets, DeepEquals, want.Offsets, Commentf("split at %d: %q", splitAt, half.keys))
+			c.Check(got.Bits, DeepEquals, want.Bits, Commentf("split at %d: %q", splitAt, half.keys))
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package critbit
+
+import (
+	"unsafe"
+)
+
+// TreeStats describes the shape and the memory use of a tree. It is
+// returned by Stats.
+type TreeStats struct {
+	NumKeys int
+
+	// The depth of a key is the number of internal nodes above it.
+	MinDepth       int
+	MaxDepth       int
+	MeanDepth      float64
+	DepthHistogram []int // the number of keys at each depth
+
+	// WalkerStackSize is the largest stack that iterating over the
+	// whole tree needed; it is usually much smaller than MaxDepth.
+	WalkerStackSize int
+
+	LiveInternalNodes int
+	FreeInternalNodes int // deleted, and waiting to be reused
+	LiveExternalRefs  int
+	FreeExternalRefs  int // deleted, and waiting to be reused
+
+	InternalNodesCapacity int // the capacities of the arrays
+	ExternalRefsCapacity  int
+
+	// KeyBytes is the sum of the lengths of the keys, as TotalStringSize
+	// returns. IndexedKeyBytes is the sum of the lengths of the sort keys
+	// the tree indexes; it differs from KeyBytes only with a collation.
+	KeyBytes        int
+	IndexedKeyBytes int
+
+	// ArenaBytes is the size of the key arena, including ArenaGarbageBytes
+	// of deleted keys; both are 0 unless WithKeyArena was given.
+	ArenaBytes        int
+	ArenaGarbageBytes int
+
+	// EstimatedHeapBytes estimates the memory held by the tree: its
+	// arrays, at their capacities, and its keys. Only the size of T is
+	// counted, not the size of anything a T points to.
+	EstimatedHeapBytes int
+
+	// FanOutByOffset counts the internal nodes which test a bit of each
+	// byte offset of the keys.
+	FanOutByOffset map[int]int
+}
+
+// Stats walks the tree, and returns statistics about it, for capacity
+// planning, or for deciding when a tree with many deletions is worth
+// rebuilding.
+func (tree *Critbit[T]) Stats() TreeStats {
+	stats := TreeStats{
+		NumKeys:               tree.numExternalRefs,
+		LiveInternalNodes:     tree.numInternalNodes,
+		FreeInternalNodes:     len(tree.internalNodes) - tree.numInternalNodes,
+		LiveExternalRefs:      tree.numExternalRefs,
+		FreeExternalRefs:      len(tree.externalRefs) - tree.numExternalRefs,
+		InternalNodesCapacity: cap(tree.internalNodes),
+		ExternalRefsCapacity:  cap(tree.externalRefs),
+		KeyBytes:              tree.totalStringSize,
+		FanOutByOffset:        make(map[int]int),
+	}
+	tree.walkStats(&stats)
+	tree.estimateHeapBytes(&stats)
+	return stats
+}
+
+func (tree *Critbit[T]) walkStats(stats *TreeStats) {
+	if tree.numExternalRefs == 0 {
+		return
+	}
+	stats.MinDepth = -1
+	totalDepth := 0
+
+	// The depth of each item on the stack is kept in a stack of its own,
+	// so that the walker stack's items stay small for everything else
+	stack := tree.newWalkerStack()
+	depths := make([]int, 0, stack.size)
+	stack.push(&walkerItem{itemType: tree.rootItemType(), itemID: tree.rootItem})
+	depths = append(depths, 0)
+	for stack.Len() > 0 {
+		walker := stack.pop()
+		depth := depths[len(depths)-1]
+		depths = depths[:len(depths)-1]
+		if walker.itemType == kChildExtRef {
+			for len(stats.DepthHistogram) <= depth {
+				stats.DepthHistogram = append(stats.DepthHistogram, 0)
+			}
+			stats.DepthHistogram[depth]++
+			if stats.MinDepth == -1 || depth < stats.MinDepth {
+				stats.MinDepth = depth
+			}
+			stats.MaxDepth = max(stats.MaxDepth, depth)
+			totalDepth += depth
+			stats.IndexedKeyBytes += len(tree.refKey(walker.itemID))
+			continue
+		}
+		node := &tree.internalNodes[walker.itemID]
+		stats.FanOutByOffset[int(node.offset)]++
+		for _, direction := range []byte{kDirectionRight, kDirectionLeft} {
+			stack.push(&walkerItem{
+				itemType: node.getChildType(direction),
+				itemID:   node.child[direction],
+			})
+			depths = append(depths, depth+1)
+		}
+	}
+	stats.MeanDepth = float64(totalDepth) / float64(tree.numExternalRefs)
+	stats.WalkerStackSize = stack.largestTop
+}
+
+func (tree *Critbit[T]) estimateHeapBytes(stats *TreeStats) {
+	const (
+		sizeofString  = int(unsafe.Sizeof(""))
+		sizeofFloat64 = 8
+		sizeofInt     = int(unsafe.Sizeof(0))
+	)
+	bytes := cap(tree.internalNodes)*int(unsafe.Sizeof(internalNode{})) +
+		cap(tree.externalRefs)*int(unsafe.Sizeof(externalRef[T]{}))
+
+	if tree.arena != nil {
+		stats.ArenaBytes = len(tree.arena.heap)
+		stats.ArenaGarbageBytes = tree.arena.garbage
+		bytes += cap(tree.arena.heap)
+	} else {
+		bytes += stats.IndexedKeyBytes
+		if tree.originalKeys != nil {
+			bytes += stats.KeyBytes
+		}
+	}
+	bytes += cap(tree.originalKeys) * sizeofString
+	if tree.scores != nil {
+		bytes += (cap(tree.scores.refScores) + cap(tree.scores.nodeMaxScores)) * sizeofFloat64
+	}
+	bytes += cap(tree.subtreeSizes)*sizeofInt + cap(tree.keyPadding)
+	stats.EstimatedHeapBytes = bytes
+}
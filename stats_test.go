@@ -0,0 +1,64 @@
+package critbit
+
+import (
+	"unsafe"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestStatsEmpty(c *C) {
+	stats := New[int](10).Stats()
+	c.Check(stats.NumKeys, Equals, 0)
+	c.Check(stats.MaxDepth, Equals, 0)
+	c.Check(stats.DepthHistogram, IsNil)
+	c.Check(stats.ExternalRefsCapacity, Equals, 10)
+	c.Check(stats.InternalNodesCapacity, Equals, 9)
+}
+
+func (s *MySuite) TestStats(c *C) {
+	tree := New[int64](0)
+	// "a" and "b" differ at offset 0; "b", "ba" and "bb" at offset 1
+	for i, key := range []string{"a", "b", "ba", "bb", "gone"} {
+		tree.Insert(key, int64(i))
+	}
+	tree.Delete("gone")
+
+	stats := tree.Stats()
+	c.Check(stats.NumKeys, Equals, 4)
+	c.Check(stats.MinDepth, Equals, 1)
+	c.Check(stats.MaxDepth, Equals, 3)
+	c.Check(stats.MeanDepth, Equals, 9.0/4)
+	c.Check(stats.DepthHistogram, DeepEquals, []int{0, 1, 1, 2})
+	c.Check(stats.WalkerStackSize > 0, Equals, true)
+	c.Check(stats.LiveInternalNodes, Equals, 3)
+	c.Check(stats.FreeInternalNodes, Equals, 1)
+	c.Check(stats.LiveExternalRefs, Equals, 4)
+	c.Check(stats.FreeExternalRefs, Equals, 1)
+	c.Check(stats.KeyBytes, Equals, 6)
+	c.Check(stats.IndexedKeyBytes, Equals, 6)
+	c.Check(stats.FanOutByOffset, DeepEquals, map[int]int{0: 1, 1: 2})
+
+	// The arrays, at their capacity, and the keys
+	minBytes := stats.InternalNodesCapacity*int(unsafe.Sizeof(internalNode{})) +
+		stats.ExternalRefsCapacity*int(unsafe.Sizeof(externalRef[int64]{})) + 6
+	c.Check(stats.EstimatedHeapBytes, Equals, minBytes)
+}
+
+func (s *MySuite) TestStatsArenaAndCollation(c *C) {
+	tree := New[int](0, WithKeyArena(0), WithCollation(func(key string) []byte {
+		return []byte(key + key)
+	}))
+	tree.Insert("abc", 1)
+	tree.Insert("de", 2)
+	tree.Delete("de")
+
+	stats := tree.Stats()
+	c.Check(stats.KeyBytes, Equals, 3)
+	c.Check(stats.IndexedKeyBytes, Equals, 6)
+	c.Check(stats.MinDepth, Equals, 0)
+	c.Check(stats.DepthHistogram, DeepEquals, []int{1})
+	// Each key is in the arena twice, as its sort key and as itself
+	c.Check(stats.ArenaBytes, Equals, 15)
+	c.Check(stats.ArenaGarbageBytes, Equals, 6)
+}
@@ -0,0 +1,189 @@
+package critbit
+
+import (
+	"github.com/pkg/errors"
+)
+
+// A SuccinctEncoding is a tree's shape, in LOUDS, with the labels needed
+// to rebuild it. The internal nodes and external refs are each listed in
+// level order, the same order in which LOUDS lists them. The fields are
+// exported so that the encoding can be marshalled with encoding/gob or
+// encoding/json, to ship a tree to another process.
+type SuccinctEncoding[T any] struct {
+	Louds LOUDS
+
+	// The offset and bit of each internal node
+	Offsets []uint32
+	Bits    []byte
+
+	// The key, as it was inserted, and the value of each external ref
+	Keys   []string
+	Values []T
+
+	// The number of bits at the end of each key's last byte which aren't
+	// part of the key, or nil if every key is a whole number of bytes
+	KeyPadding []uint8
+}
+
+// Succinct returns the tree's SuccinctEncoding. FromLouds rebuilds the
+// tree from it. Scores are not included.
+func (tree *Critbit[T]) Succinct() *SuccinctEncoding[T] {
+	encoding := &SuccinctEncoding[T]{
+		Louds:   tree.Louds(),
+		Offsets: make([]uint32, 0, tree.numInternalNodes),
+		Bits:    make([]byte, 0, tree.numInternalNodes),
+		Keys:    make([]string, 0, tree.numExternalRefs),
+		Values:  make([]T, 0, tree.numExternalRefs),
+	}
+	tree.walkLevelOrder(func(itemType byte, itemID nodeIndex) {
+		if itemType == kChildExtRef {
+			encoding.Keys = append(encoding.Keys, tree.refOriginalKey(itemID))
+			encoding.Values = append(encoding.Values, tree.externalRefs[itemID].value)
+			if tree.keyPadding != nil {
+				encoding.KeyPadding = append(encoding.KeyPadding, tree.keyPadding[itemID])
+			}
+			return
+		}
+		node := &tree.internalNodes[itemID]
+		encoding.Offsets = append(encoding.Offsets, uint32(node.offset))
+		encoding.Bits = append(encoding.Bits, node.bit)
+	})
+	return encoding
+}
+
+// FromLouds rebuilds a tree from a SuccinctEncoding, which Succinct
+// returned. The tree has the same shape and keys as the encoded tree, and
+// its internal nodes and external refs are numbered in level order. The
+// options should be the same as the encoded tree's; if they have a
+// different collation, the keys are re-collated, and the encoding is
+// likely to be invalid. FromLouds returns an error if the encoding doesn't
+// describe a valid tree.
+func FromLouds[T any](encoding *SuccinctEncoding[T], options ...Option) (*Critbit[T], error) {
+	numNodes := len(encoding.Offsets)
+	numRefs := len(encoding.Keys)
+	if len(encoding.Bits) != numNodes {
+		return nil, errors.Errorf("Encoding has %d offsets but %d bits", numNodes, len(encoding.Bits))
+	}
+	if len(encoding.Values) != numRefs {
+		return nil, errors.Errorf("Encoding has %d keys but %d values", numRefs, len(encoding.Values))
+	}
+	if encoding.KeyPadding != nil && len(encoding.KeyPadding) != numRefs {
+		return nil, errors.Errorf("Encoding has %d keys but %d key paddings", numRefs, len(encoding.KeyPadding))
+	}
+	if numRefs > 0 && numNodes != numRefs-1 || numRefs == 0 && numNodes != 0 {
+		return nil, errors.Errorf("Encoding has %d keys but %d internal nodes", numRefs, numNodes)
+	}
+
+	// The types of the items, in level order
+	itemTypes, err := encoding.Louds.itemTypes()
+	if err != nil {
+		return nil, err
+	}
+	if len(itemTypes) != numNodes+numRefs {
+		return nil, errors.Errorf("LOUDS has %d items, but the encoding has %d internal nodes and %d keys",
+			len(itemTypes), numNodes, numRefs)
+	}
+
+	tree := New[T](numRefs, options...)
+	if numRefs == 0 {
+		return tree, nil
+	}
+
+	// Give each item its ID, which is its position among the items of
+	// the same type.
+	itemIDs := make([]nodeIndex, len(itemTypes))
+	var nextNodeNum, nextRefNum nodeIndex
+	for i, itemType := range itemTypes {
+		if itemType == kChildIntNode {
+			if uint64(encoding.Offsets[nextNodeNum]) >= kMaxStringLength {
+				return nil, errors.Errorf("Internal node %d has offset %d, but the maximum is %d",
+					nextNodeNum, encoding.Offsets[nextNodeNum], uint64(kMaxStringLength-1))
+			}
+			nodeNum, node := tree.addInternalNode()
+			node.offset = keyOffset(encoding.Offsets[nextNodeNum])
+			node.bit = encoding.Bits[nextNodeNum]
+			itemIDs[i] = nodeNum
+			nextNodeNum++
+		} else {
+			key := encoding.Keys[nextRefNum]
+			if uint64(len(key)) > kMaxStringLength {
+				return nil, errors.Errorf("Key %d is %d bytes long, but the maximum is %d",
+					nextRefNum, len(key), uint64(kMaxStringLength))
+			}
+			sortKey, keyBits := tree.collate(key), bitLen(key)
+			if encoding.KeyPadding != nil {
+				padding := int(encoding.KeyPadding[nextRefNum])
+				if padding != 0 && (padding > 7 || len(key) == 0 || tree.config.collation != nil) {
+					return nil, errors.Errorf("Key %d has %d bits of padding, which is invalid",
+						nextRefNum, padding)
+				}
+				keyBits -= int64(padding)
+			}
+			refNum, err := tree.addExternalRef(sortKey, keyBits, key, encoding.Values[nextRefNum])
+			if err != nil {
+				return nil, err
+			}
+			itemIDs[i] = refNum
+			nextRefNum++
+		}
+	}
+
+	// In level order, the children of the j'th internal node are the
+	// items after the root at 2j and 2j+1.
+	tree.rootItem = itemIDs[0]
+	j := 0
+	for i, itemType := range itemTypes {
+		if itemType != kChildIntNode {
+			continue
+		}
+		node := &tree.internalNodes[itemIDs[i]]
+		for direction := byte(0); direction < 2; direction++ {
+			child := 1 + 2*j + int(direction)
+			node.setChild(direction, itemIDs[child], itemTypes[child])
+		}
+		j++
+	}
+	tree.refreshAllAugments(tree.rootItemType(), tree.rootItem)
+
+	if err := tree.Validate(); err != nil {
+		return nil, errors.Wrap(err, "Encoding is not a valid tree")
+	}
+	return tree, nil
+}
+
+// Returns the type of each item in the LOUDS, in level order. Every item
+// in a critbit tree has either 0 or 2 children.
+func (s LOUDS) itemTypes() ([]byte, error) {
+	if len(s) == 1 && s[0] == 0 {
+		return nil, nil
+	}
+	// The super-root has one child, the root
+	if len(s) < 2 || s[0] != 1 || s[1] != 0 {
+		return nil, errors.Errorf("LOUDS doesn't start with the super-root")
+	}
+	var itemTypes []byte
+	// The number of items which have been named as children, but whose
+	// degrees haven't been read yet
+	pending := 1
+	for i := 2; i < len(s); {
+		if pending == 0 {
+			return nil, errors.Errorf("LOUDS has extra bits at %d", i)
+		}
+		pending--
+		switch {
+		case s[i] == 0:
+			itemTypes = append(itemTypes, kChildExtRef)
+			i++
+		case i+2 < len(s) && s[i] == 1 && s[i+1] == 1 && s[i+2] == 0:
+			itemTypes = append(itemTypes, kChildIntNode)
+			pending += 2
+			i += 3
+		default:
+			return nil, errors.Errorf("LOUDS has an item at %d without 0 or 2 children", i)
+		}
+	}
+	if pending != 0 {
+		return nil, errors.Errorf("LOUDS is missing %d items", pending)
+	}
+	return itemTypes, nil
+}
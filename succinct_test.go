@@ -0,0 +1,136 @@
+package critbit
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+// Checks that a tree rebuilt from its SuccinctEncoding has the same
+// shape, labels, keys and values.
+func (s *MySuite) checkSuccinctRoundTrip(c *C, tree *Critbit[int], options ...Option) *Critbit[int] {
+	encoding := tree.Succinct()
+	c.Check(encoding.Offsets, HasLen, tree.numInternalNodes)
+	c.Check(encoding.Keys, HasLen, tree.numExternalRefs)
+
+	rebuilt, err := FromLouds(encoding, options...)
+	c.Assert(err, IsNil)
+	c.Check(rebuilt.Validate(), IsNil)
+	c.Check(compareLouds(rebuilt, tree, "succinct", "rebuilt", tree.Length()), Equals, true)
+	c.Check(rebuilt.Succinct(), DeepEquals, encoding)
+	c.Check(rebuilt.TotalStringSize(), Equals, tree.TotalStringSize())
+
+	var want, got []KeyValueTuple[int]
+	for key, value := range tree.IterateItems() {
+		want = append(want, KeyValueTuple[int]{key, value})
+	}
+	for key, value := range rebuilt.IterateItems() {
+		got = append(got, KeyValueTuple[int]{key, value})
+	}
+	c.Check(got, DeepEquals, want)
+	return rebuilt
+}
+
+func (s *MySuite) TestSuccinct(c *C) {
+	tree := New[int](0)
+	s.checkSuccinctRoundTrip(c, tree)
+
+	for i, key := range []string{"naa", "a", "", "b", "nab", "a\x00", "nba", "m", "nac", "abc"} {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+		if i < 2 {
+			s.checkSuccinctRoundTrip(c, tree)
+		}
+	}
+	rebuilt := s.checkSuccinctRoundTrip(c, tree)
+
+	// The rebuilt tree can be changed like any other
+	_, err := rebuilt.Insert("nad", 10)
+	c.Assert(err, IsNil)
+	rebuilt.Delete("a")
+	c.Check(rebuilt.Validate(), IsNil)
+	value, found := rebuilt.Get("nad")
+	c.Check(found, Equals, true)
+	c.Check(value, Equals, 10)
+
+	// A tree with deleted slots is rebuilt without them
+	tree.Delete("b")
+	tree.Delete("naa")
+	rebuilt = s.checkSuccinctRoundTrip(c, tree)
+	c.Check(rebuilt.internalNodes, HasLen, tree.numInternalNodes)
+	c.Check(rebuilt.externalRefs, HasLen, tree.numExternalRefs)
+}
+
+func (s *MySuite) TestSuccinctOptions(c *C) {
+	options := []Option{WithCollation(FoldASCII), WithKeyArena(0.5)}
+	tree := New[int](0, options...)
+	for i, key := range []string{"carol", "Alice", "Bob", "alfred"} {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+	}
+	rebuilt := s.checkSuccinctRoundTrip(c, tree, options...)
+	value, found := rebuilt.Get("ALICE")
+	c.Check(found, Equals, true)
+	c.Check(value, Equals, 1)
+	c.Check(rebuilt.Keys(), DeepEquals, []string{"alfred", "Alice", "Bob", "carol"})
+}
+
+func (s *MySuite) TestSuccinctGob(c *C) {
+	tree := New[int](0)
+	for i := 0; i < 100; i++ {
+		_, err := tree.Insert(fmt.Sprintf("key%d", i*7), i)
+		c.Assert(err, IsNil)
+	}
+	var buf bytes.Buffer
+	c.Assert(gob.NewEncoder(&buf).Encode(tree.Succinct()), IsNil)
+
+	var encoding SuccinctEncoding[int]
+	c.Assert(gob.NewDecoder(&buf).Decode(&encoding), IsNil)
+	rebuilt, err := FromLouds(&encoding)
+	c.Assert(err, IsNil)
+	c.Check(compareLouds(rebuilt, tree, "succinct", "gob", 100), Equals, true)
+	value, found := rebuilt.Get("key693")
+	c.Check(found, Equals, true)
+	c.Check(value, Equals, 99)
+}
+
+func (s *MySuite) TestFromLoudsInvalid(c *C) {
+	tree := New[int](0)
+	for i, key := range []string{"a", "b", "c"} {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+	}
+
+	table := []struct {
+		corrupt func(e *SuccinctEncoding[int])
+		err     string
+	}{
+		{func(e *SuccinctEncoding[int]) { e.Bits = e.Bits[:1] }, "Encoding has 2 offsets but 1 bits"},
+		{func(e *SuccinctEncoding[int]) { e.Values = e.Values[:1] }, "Encoding has 3 keys but 1 values"},
+		{func(e *SuccinctEncoding[int]) {
+			e.Keys = append(e.Keys, "d")
+			e.Values = append(e.Values, 3)
+		}, "Encoding has 4 keys but 2 internal nodes"},
+		{func(e *SuccinctEncoding[int]) { e.Louds = e.Louds[1:] }, "LOUDS doesn't start with the super-root"},
+		{func(e *SuccinctEncoding[int]) { e.Louds = append(e.Louds, 0) }, "LOUDS has extra bits at .*"},
+		{func(e *SuccinctEncoding[int]) { e.Louds = e.Louds[:len(e.Louds)-1] }, "LOUDS is missing 1 items"},
+		{func(e *SuccinctEncoding[int]) { e.Louds[2+1] = 0 }, "LOUDS has an item at 2 without 0 or 2 children"},
+		{func(e *SuccinctEncoding[int]) {
+			e.Louds = LOUDS{1, 0, 1, 1, 0, 0, 0}
+		}, "LOUDS has 3 items, but the encoding has 2 internal nodes and 3 keys"},
+		{func(e *SuccinctEncoding[int]) { e.Keys[0], e.Keys[1] = e.Keys[1], e.Keys[0] },
+			"Encoding is not a valid tree: .*"},
+		{func(e *SuccinctEncoding[int]) { e.Bits[0] = 0x05 }, "Encoding is not a valid tree: .*"},
+		{func(e *SuccinctEncoding[int]) { e.Offsets[1] = 1 << 31 }, ".*offset.*"},
+	}
+	for i, test := range table {
+		encoding := tree.Succinct()
+		test.corrupt(encoding)
+		rebuilt, err := FromLouds(encoding)
+		c.Check(rebuilt, IsNil, Commentf("test %d", i))
+		c.Check(err, ErrorMatches, test.err, Commentf("test %d", i))
+	}
+}
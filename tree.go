@@ -6,6 +6,10 @@ import (
 	"github.com/pkg/errors"
 )
 
+// maxStrings is the maximum number of external refs. It is a variable,
+// rather than the kMaxStrings constant, only so that tests can lower it.
+var maxStrings uint64 = kMaxStrings
+
 // Returns the node type of the root node
 func (tree *Critbit[T]) rootItemType() byte {
 	switch tree.numExternalRefs {
@@ -18,38 +22,113 @@ func (tree *Critbit[T]) rootItemType() byte {
 	}
 }
 
-func (tree *Critbit[T]) addExternalRef(key string, value T) (uint32, error) {
-	var refNum uint32
+// The key is the sort key which the tree indexes, and keyBits is its
+// length in bits. Without a collation, the original key is the same as
+// the key.
+func (tree *Critbit[T]) addExternalRef(key string, keyBits int64, original string, value T) (nodeIndex, error) {
+	var refNum nodeIndex
 	if tree.firstDeletedRef == kNilRef {
-		if refNum == kMaxStrings {
+		// With no deleted refs to reuse, the next refNum is the
+		// length of the array; it must not reach kNilRef.
+		if uint64(len(tree.externalRefs)) >= maxStrings {
 			return 0, errors.Errorf("Critbit is full")
 		}
-		refNum = uint32(len(tree.externalRefs))
-		tree.externalRefs = append(tree.externalRefs, externalRef[T]{key, value, 0})
+		refNum = nodeIndex(len(tree.externalRefs))
+		tree.externalRefs = append(tree.externalRefs, externalRef[T]{"", value, 0})
 	} else {
 		refNum = tree.firstDeletedRef
 		tree.firstDeletedRef = tree.externalRefs[refNum].nextDeletedRef
-		tree.externalRefs[int(refNum)].key = key
 		tree.externalRefs[int(refNum)].value = value
 		tree.externalRefs[int(refNum)].nextDeletedRef = 0
 	}
+	if tree.arena != nil {
+		if tree.originalKeys != nil {
+			key, original = tree.addArenaKey(key, original)
+		} else {
+			key, _ = tree.addArenaKey(key, "")
+		}
+	}
+	tree.externalRefs[int(refNum)].key = key
+	if tree.originalKeys != nil {
+		if int(refNum) == len(tree.originalKeys) {
+			tree.originalKeys = append(tree.originalKeys, original)
+		} else {
+			tree.originalKeys[refNum] = original
+		}
+	}
+	if padding := bitLen(key) - keyBits; padding != 0 || tree.keyPadding != nil {
+		tree.setKeyPadding(refNum, uint8(padding))
+	}
+	tree.totalStringSize += len(original)
 	tree.numExternalRefs++
+	if tree.scores != nil {
+		tree.scores.addRef(refNum)
+	}
 	return refNum, nil
 }
 
-func (tree *Critbit[T]) deleteExternalRef(refNum uint32) {
+// The padding of a key is the number of bits at the end of its last byte
+// which aren't part of the key. Until a key has padding, none of the keys
+// do, so the array isn't allocated.
+func (tree *Critbit[T]) setKeyPadding(refNum nodeIndex, padding uint8) {
+	if tree.keyPadding == nil {
+		tree.keyPadding = make([]uint8, len(tree.externalRefs))
+	}
+	if int(refNum) == len(tree.keyPadding) {
+		tree.keyPadding = append(tree.keyPadding, padding)
+	} else {
+		tree.keyPadding[refNum] = padding
+	}
+}
+
+func (tree *Critbit[T]) deleteExternalRef(refNum nodeIndex) {
 	var nilVal T
 	tree.numExternalRefs--
+	tree.totalStringSize -= len(tree.refOriginalKey(refNum))
+	size := len(tree.externalRefs[refNum].key)
+	if tree.originalKeys != nil {
+		size += len(tree.originalKeys[refNum])
+		tree.originalKeys[refNum] = ""
+	}
+	if tree.keyPadding != nil {
+		tree.keyPadding[refNum] = 0
+	}
 	tree.externalRefs[refNum].key = ""
 	tree.externalRefs[refNum].value = nilVal
 	tree.externalRefs[refNum].nextDeletedRef = tree.firstDeletedRef
 	tree.firstDeletedRef = refNum
+	if tree.arena != nil {
+		tree.removeArenaKey(size)
+	}
+}
+
+// refKey returns the key stored in an external ref; this is the sort
+// key, if the tree has a collation.
+func (tree *Critbit[T]) refKey(refNum nodeIndex) string {
+	return tree.externalRefs[refNum].key
 }
 
-func (tree *Critbit[T]) addInternalNode() (uint32, *internalNode) {
-	var nodeNum uint32
+// refBits returns the length in bits of the key stored in an external
+// ref, which is key.
+func (tree *Critbit[T]) refBits(refNum nodeIndex, key string) int64 {
+	if tree.keyPadding == nil {
+		return bitLen(key)
+	}
+	return bitLen(key) - int64(tree.keyPadding[refNum])
+}
+
+// refOriginalKey returns the key of an external ref as it was inserted.
+func (tree *Critbit[T]) refOriginalKey(refNum nodeIndex) string {
+	if tree.config.collation == nil {
+		return tree.refKey(refNum)
+	}
+	return tree.originalKeys[refNum]
+}
+
+func (tree *Critbit[T]) addInternalNode() (nodeIndex, *internalNode) {
+	var nodeNum nodeIndex
 	if tree.firstDeletedNode == kNilNode {
-		nodeNum = uint32(len(tree.internalNodes))
+		nodeNum = nodeIndex(len(tree.internalNodes))
 		tree.internalNodes = append(tree.internalNodes, internalNode{})
 	} else {
 		nodeNum = tree.firstDeletedNode
@@ -59,17 +138,33 @@ func (tree *Critbit[T]) addInternalNode() (uint32, *internalNode) {
 		tree.internalNodes[int(nodeNum)].child[1] = kNilNode
 	}
 	tree.numInternalNodes++
+	if tree.scores != nil {
+		tree.scores.addNode(nodeNum)
+	}
+	if tree.subtreeSizes != nil && int(nodeNum) == len(tree.subtreeSizes) {
+		tree.subtreeSizes = append(tree.subtreeSizes, 0)
+	}
 	return nodeNum, &tree.internalNodes[nodeNum]
 }
 
-func (tree *Critbit[T]) deleteInternalNode(nodeNum uint32) {
+func (tree *Critbit[T]) deleteInternalNode(nodeNum nodeIndex) {
 	tree.numInternalNodes--
 	tree.internalNodes[nodeNum].child[1] = tree.firstDeletedNode
 	tree.firstDeletedNode = nodeNum
 }
 
+// Returns the refNum of the smallest key under an item
+func (tree *Critbit[T]) leftmostRef(itemType byte, itemID nodeIndex) nodeIndex {
+	for itemType == kChildIntNode {
+		node := &tree.internalNodes[itemID]
+		itemType = node.getChildType(kDirectionLeft)
+		itemID = node.child[kDirectionLeft]
+	}
+	return itemID
+}
+
 // The caller must ensure that rootItem is valid (either a ref or a node)
-func (tree *Critbit[T]) findBestExternalReference(key string) uint32 {
+func (tree *Critbit[T]) findBestExternalReference(key string, keyBits int64) nodeIndex {
 	// If there is only one ref, then it must be the best choice
 	if tree.numExternalRefs == 1 {
 		return tree.rootItem
@@ -78,7 +173,7 @@ func (tree *Critbit[T]) findBestExternalReference(key string) uint32 {
 	nodeNum := tree.rootItem
 	for {
 		node := &tree.internalNodes[nodeNum]
-		direction := node.direction(key)
+		direction := node.direction(key, keyBits)
 		childType := node.getChildType(direction)
 		switch childType {
 		case kChildIntNode:
@@ -94,16 +189,16 @@ func (tree *Critbit[T]) findBestExternalReference(key string) uint32 {
 
 // The caller must ensure that rootItem is valid (either a ref or a node)
 // Returns extRefNum, grandparentNodeNum, grandparentDirection, parentNodeNum, parentDirection, parentIsRoot
-func (tree *Critbit[T]) findBestExternalReferenceWithAncestry(key string) (uint32, uint32, byte, uint32, byte, bool) {
+func (tree *Critbit[T]) findBestExternalReferenceWithAncestry(key string, keyBits int64) (nodeIndex, nodeIndex, byte, nodeIndex, byte, bool) {
 	// If there is only one ref, then it must be the best choice
 	if tree.numExternalRefs == 1 {
 		return tree.rootItem, 0, 0, 0, 0, false
 	}
 
 	var parentIsRoot bool = true
-	var grandparentNodeNum uint32
+	var grandparentNodeNum nodeIndex
 	var grandparentDirection byte
-	var parentNodeNum uint32
+	var parentNodeNum nodeIndex
 	var parentDirection byte
 
 	nodeNum := tree.rootItem
@@ -111,7 +206,7 @@ func (tree *Critbit[T]) findBestExternalReferenceWithAncestry(key string) (uint3
 		node := &tree.internalNodes[nodeNum]
 
 		grandparentDirection = parentDirection
-		parentDirection = node.direction(key)
+		parentDirection = node.direction(key, keyBits)
 		grandparentNodeNum = parentNodeNum
 		parentNodeNum = nodeNum
 
@@ -130,31 +225,46 @@ func (tree *Critbit[T]) findBestExternalReferenceWithAncestry(key string) (uint3
 }
 
 // Returns identical, off, bit, ndir, err
-func (tree *Critbit[T]) findCriticalBit(refNum uint32, newKey string) (bool, uint16, byte, byte) {
-	return findCriticalBit(tree.externalRefs[refNum].key, newKey)
+func (tree *Critbit[T]) findCriticalBit(refNum nodeIndex, newKey string, newBits int64) (bool, keyOffset, byte, byte) {
+	storedKey := tree.refKey(refNum)
+	return findCriticalBit(storedKey, tree.refBits(refNum, storedKey), newKey, newBits)
 }
 
-// Returns identical, off, bit, ndir
-func findCriticalBit(storedKey string, newKey string) (bool, uint16, byte, byte) {
-	// find critical bit
-	var off uint16
+// Returns identical, off, bit, ndir. ndir is the direction of the stored
+// key at the new node. The keys' lengths are in bits, and only the bits
+// within them are compared. If one key is a prefix of the other, the bit
+// marks the end of the shorter key; see endOfKeyBit.
+func findCriticalBit(storedKey string, storedBits int64, newKey string, newBits int64) (bool, keyOffset, byte, byte) {
+	// find critical bit. The loop runs over an int, not a keyOffset,
+	// so that a key whose length is exactly MaxStringLength doesn't
+	// wrap the offset around to zero.
+	var off int
 	var ch, bit byte
+	commonBits := min(storedBits, newBits)
 	// find differing byte
-	for off = 0; off < uint16(len(newKey)); off++ {
-		if ch = 0; off < uint16(len(storedKey)) {
-			ch = storedKey[off]
-		}
-		if keych := newKey[off]; ch != keych {
-			bit = ch ^ keych
+	commonLength := int(commonBits / 8)
+	for off = 0; off < commonLength; off++ {
+		if ch = storedKey[off]; ch != newKey[off] {
+			bit = ch ^ newKey[off]
 			goto ByteFound
 		}
 	}
-	if off < uint16(len(storedKey)) {
+	// The shorter key may end partway through the next byte
+	if commonBits%8 != 0 {
 		ch = storedKey[off]
-		bit = ch
-		goto ByteFound
+		if bit = (ch ^ newKey[off]) & (0xff << (8 - int(commonBits%8))); bit != 0 {
+			goto ByteFound
+		}
 	}
-	return true, 0, 0, 0
+	if storedBits == newBits {
+		return true, 0, 0, 0
+	}
+	// The stored key continues past the new key, or vice versa
+	bit = endOfKeyBit(int(commonBits % 8))
+	if storedBits > newBits {
+		return false, keyOffset(off), bit, 1
+	}
+	return false, keyOffset(off), bit, 0
 
 ByteFound:
 	// find differing bit
@@ -166,14 +276,46 @@ ByteFound:
 	if ch&bit != 0 {
 		ndir++
 	}
-	return false, off, bit, ndir
+	return false, keyOffset(off), bit, ndir
+}
+
+// Returns the significance of a node's bit within its offset. The end of
+// the keys which end partway through the byte ranks between the last bit
+// which they have and the next one; kEndOfKey ranks above every bit.
+func bitRank(bit byte) uint16 {
+	switch {
+	case bit == kEndOfKey:
+		return 0x101
+	case bit&(bit-1) != 0:
+		return uint16(bit&(bit>>1))<<1 | 1
+	}
+	return uint16(bit) << 1
+}
+
+// Returns -1, 0 or 1 as a key of aBits bits sorts before, the same as,
+// or after a key of bBits bits.
+func compareKeys(a string, aBits int64, b string, bBits int64) int {
+	identical, _, _, ndir := findCriticalBit(a, aBits, b, bBits)
+	switch {
+	case identical:
+		return 0
+	case ndir == kDirectionRight:
+		return 1
+	}
+	return -1
+}
+
+// Compares the key of a ref with a key of keyBits bits, like compareKeys
+func (tree *Critbit[T]) compareRef(refNum nodeIndex, key string, keyBits int64) int {
+	refKey := tree.refKey(refNum)
+	return compareKeys(refKey, tree.refBits(refNum, refKey), key, keyBits)
 }
 
 // The caller must ensure that there is at least one internal node
 // Returns nodeNum, parentNode, prevDirection, insertAtRoot, finalChildType
-func (tree *Critbit[T]) findBranchNode(off uint16, bit byte,
-	key string) (uint32, uint32, byte, bool, byte) {
-	var parentNodeNum uint32 = 0
+func (tree *Critbit[T]) findBranchNode(off keyOffset, bit byte,
+	key string, keyBits int64) (nodeIndex, nodeIndex, byte, bool, byte) {
+	var parentNodeNum nodeIndex = 0
 	var prevDirection byte
 	var insertAtRoot bool = true
 
@@ -181,11 +323,11 @@ func (tree *Critbit[T]) findBranchNode(off uint16, bit byte,
 
 	for {
 		node := &tree.internalNodes[nodeNum]
-		if node.offset > off || node.offset == off && node.bit < bit {
+		if node.offset > off || node.offset == off && bitRank(node.bit) < bitRank(bit) {
 			return nodeNum, parentNodeNum, prevDirection, insertAtRoot, kChildIntNode
 		}
 		// try the next node
-		direction := node.direction(key)
+		direction := node.direction(key, keyBits)
 		childType := node.getChildType(direction)
 		switch childType {
 		case kChildIntNode:
@@ -200,6 +342,4 @@ func (tree *Critbit[T]) findBranchNode(off uint16, bit byte,
 				direction, nodeNum, childType))
 		}
 	}
-	panic("should not reach here")
-	return 0, 0, 0, false, 0
 }
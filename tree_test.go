@@ -1,16 +1,21 @@
 package critbit
 
 import (
+	"strings"
+
 	// Bring the symbols in check.v1 into this namespace
 	. "gopkg.in/check.v1"
 )
 
 func (s *MySuite) TestFindCritBit(c *C) {
 	// Returns identical, off, bit, ndir
-	//func findCriticalBit(storedKey string, newKey string) (bool, uint16, byte, byte) {
+	//func findCriticalBit(storedKey string, storedBits int, newKey string, newBits int) (bool, keyOffset, byte, byte) {
+	findCriticalBit := func(storedKey string, newKey string) (bool, keyOffset, byte, byte) {
+		return findCriticalBit(storedKey, bitLen(storedKey), newKey, bitLen(newKey))
+	}
 
 	var identical bool
-	var off uint16
+	var off keyOffset
 	var bit byte
 	var ndir byte
 
@@ -18,7 +23,7 @@ func (s *MySuite) TestFindCritBit(c *C) {
 	identical, off, bit, ndir = findCriticalBit("@", "A")
 	//	log.Printf("identical=%v off=%d bit=0x%0x ndir=%d", identical, off, uint8(bit), ndir)
 	c.Check(identical, Equals, false)
-	c.Check(off, Equals, uint16(0))
+	c.Check(off, Equals, keyOffset(0))
 	c.Check(bit, Equals, uint8(1))
 	c.Check(ndir, Equals, uint8(0))
 
@@ -26,13 +31,73 @@ func (s *MySuite) TestFindCritBit(c *C) {
 	identical, off, bit, ndir = findCriticalBit("A", "@")
 	//	log.Printf("identical=%v off=%d bit=0x%0x ndir=%d", identical, off, uint8(bit), ndir)
 	c.Check(identical, Equals, false)
-	c.Check(off, Equals, uint16(0))
+	c.Check(off, Equals, keyOffset(0))
 	c.Check(bit, Equals, uint8(1))
 	c.Check(ndir, Equals, uint8(1))
 
 	identical, off, bit, ndir = findCriticalBit("A", "A")
 	//	log.Printf("identical=%v off=%d bit=0x%0x ndir=%d", identical, off, uint8(bit), ndir)
 	c.Check(identical, Equals, true)
+
+	// The last byte of a 64k key. The offset used to be a uint16 loop
+	// counter, which wrapped to zero and reported the keys as identical.
+	base := strings.Repeat("x", 1<<16-1)
+	identical, off, bit, ndir = findCriticalBit(base+"@", base+"A")
+	c.Check(identical, Equals, false)
+	c.Check(off, Equals, keyOffset(1<<16-1))
+	c.Check(bit, Equals, uint8(1))
+	c.Check(ndir, Equals, uint8(0))
+}
+
+func (s *MySuite) TestFindCritBitPartialBytes(c *C) {
+	// The padding bits after the end of a key are not compared
+	identical, _, _, _ := findCriticalBit("\xa0", 3, "\xbf", 3)
+	c.Check(identical, Equals, true)
+
+	// 101 is a prefix of 1011, so its end is marked between the 3rd and
+	// 4th bits
+	identical, off, bit, ndir := findCriticalBit("\xa0", 3, "\xb0", 4)
+	c.Check(identical, Equals, false)
+	c.Check(off, Equals, keyOffset(0))
+	c.Check(bit, Equals, endOfKeyBit(3))
+	c.Check(bit, Equals, uint8(0x30))
+	c.Check(ndir, Equals, uint8(0))
+
+	// A 1-bit key is a prefix of the whole byte which starts with it
+	identical, off, bit, ndir = findCriticalBit("\x80", 8, "\x80", 1)
+	c.Check(identical, Equals, false)
+	c.Check(off, Equals, keyOffset(0))
+	c.Check(bit, Equals, uint8(0xc0))
+	c.Check(ndir, Equals, uint8(1))
+
+	// The keys differ within the shorter key's bits
+	identical, off, bit, ndir = findCriticalBit("x\x80", 9, "x\x7f", 16)
+	c.Check(identical, Equals, false)
+	c.Check(off, Equals, keyOffset(1))
+	c.Check(bit, Equals, uint8(0x80))
+	c.Check(ndir, Equals, uint8(1))
+
+	// A key which ends at a byte boundary is marked with kEndOfKey
+	identical, off, bit, ndir = findCriticalBit("x", 8, "x\x00", 9)
+	c.Check(identical, Equals, false)
+	c.Check(off, Equals, keyOffset(1))
+	c.Check(bit, Equals, uint8(kEndOfKey))
+	c.Check(ndir, Equals, uint8(0))
+}
+
+func (s *MySuite) TestBitRank(c *C) {
+	// From the most significant test within a byte to the least
+	order := []byte{kEndOfKey, 0x80, 0xc0, 0x40, 0x60, 0x20, 0x30, 0x10,
+		0x18, 0x08, 0x0c, 0x04, 0x06, 0x02, 0x03, 0x01}
+	for i := 1; i < len(order); i++ {
+		c.Check(bitRank(order[i-1]) > bitRank(order[i]), Equals, true,
+			Commentf("0x%02x, 0x%02x", order[i-1], order[i]))
+		c.Check(bitIndex(order[i-1]) <= bitIndex(order[i]), Equals, true)
+	}
+	for n := 1; n < 8; n++ {
+		c.Check(isEndOfKey(endOfKeyBit(n)), Equals, true)
+		c.Check(bitIndex(endOfKeyBit(n)), Equals, n)
+	}
 }
 
 // This tests a fix for the issue #1 that aletheia7 found.
@@ -49,3 +114,28 @@ func (s *MySuite) TestInsertAfterDelete(c *C) {
 	trie.Delete("green")
 	trie.Insert("yellow", 1)
 }
+
+func (s *MySuite) TestFull(c *C) {
+	defer func(saved uint64) { maxStrings = saved }(maxStrings)
+	maxStrings = 3
+
+	tree := New[int](0)
+	for i, key := range []string{"a", "b", "c"} {
+		ok, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+		c.Check(ok, Equals, true)
+	}
+
+	ok, err := tree.Insert("d", 3)
+	c.Check(err, ErrorMatches, ".*Critbit is full")
+	c.Check(ok, Equals, false)
+	c.Check(tree.Upsert("d", 3), ErrorMatches, ".*Critbit is full")
+	c.Check(tree.Length(), Equals, 3)
+
+	// A deleted ref can be reused, even when the tree is full
+	c.Check(tree.Delete("b"), Equals, true)
+	ok, err = tree.Insert("d", 3)
+	c.Assert(err, IsNil)
+	c.Check(ok, Equals, true)
+	c.Check(tree.Keys(), DeepEquals, []string{"a", "c", "d"})
+}
@@ -3,7 +3,8 @@ package critbit
 // Update changes the value for the given key. If the key is
 // not stored in the tree, the returned bool value is false.
 func (tree *Critbit[T]) Update(key string, value T) bool {
-	has, refNum := tree.findRef(key)
+	key = tree.collate(key)
+	has, refNum := tree.findRef(key, bitLen(key))
 	if !has {
 		return false
 	}
@@ -16,15 +17,19 @@ func (tree *Critbit[T]) Update(key string, value T) bool {
 // Since the act of inserting a key may return an error, Upsert also
 // returns an error, indicating if inseration failed.
 func (tree *Critbit[T]) Upsert(key string, value T) error {
-	has, refNum := tree.findRef(key)
+	sortKey := tree.collate(key)
+	has, refNum := tree.findRef(sortKey, bitLen(sortKey))
 	if has {
 		tree.externalRefs[refNum].value = value
 		return nil
 	} else {
-		inserted, err := tree.Insert(key, value)
+		inserted, err := tree.insert(sortKey, bitLen(sortKey), key, value)
+		if err != nil {
+			return err
+		}
 		if !inserted {
 			panic("Insert should have succeeded")
 		}
-		return err
+		return nil
 	}
 }
@@ -0,0 +1,249 @@
+package critbit
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Validate checks the structure of the tree, and returns an error
+// describing the first problem it finds, or nil. It checks that:
+//
+//   - every internal node has two non-nil children, and tests one bit or
+//     marks one end of keys
+//   - the (offset, bit) pairs strictly increase along every path
+//   - every key sits on the side of each node that its bits dictate, and
+//     shares the bits before each node's critical bit with its neighbours
+//   - the padding bits at the end of a key which ends partway through a
+//     byte are 0
+//   - every live node and ref is reachable from the root, exactly once
+//   - numInternalNodes == numExternalRefs - 1
+//   - the free lists are acyclic, and hold only the unreachable slots
+//   - the subtree summaries (maximum scores and sizes), if kept, are right
+//
+// It takes O(n * depth) time, so it's meant for tests and debugging.
+func (tree *Critbit[T]) Validate() error {
+	if tree.numExternalRefs == 0 {
+		if tree.numInternalNodes != 0 {
+			return errors.Errorf("Tree has no refs but %d internal nodes", tree.numInternalNodes)
+		}
+	} else if tree.numInternalNodes != tree.numExternalRefs-1 {
+		return errors.Errorf("Tree has %d refs but %d internal nodes",
+			tree.numExternalRefs, tree.numInternalNodes)
+	}
+
+	if tree.scores != nil && (len(tree.scores.refScores) != len(tree.externalRefs) ||
+		len(tree.scores.nodeMaxScores) != len(tree.internalNodes)) {
+		return errors.Errorf("Tree has %d refs and %d nodes, but scores for %d and %d",
+			len(tree.externalRefs), len(tree.internalNodes),
+			len(tree.scores.refScores), len(tree.scores.nodeMaxScores))
+	}
+	if tree.subtreeSizes != nil && len(tree.subtreeSizes) != len(tree.internalNodes) {
+		return errors.Errorf("Tree has %d nodes, but subtree sizes for %d",
+			len(tree.internalNodes), len(tree.subtreeSizes))
+	}
+	if tree.keyPadding != nil && len(tree.keyPadding) != len(tree.externalRefs) {
+		return errors.Errorf("Tree has %d refs, but key paddings for %d",
+			len(tree.externalRefs), len(tree.keyPadding))
+	}
+
+	v := &validator[T]{
+		tree:         tree,
+		reachedNodes: make([]bool, len(tree.internalNodes)),
+		reachedRefs:  make([]bool, len(tree.externalRefs)),
+	}
+	if err := v.validateItem(tree.rootItemType(), tree.rootItem); err != nil {
+		return err
+	}
+	if v.numNodes != tree.numInternalNodes {
+		return errors.Errorf("Reached %d of %d internal nodes from the root",
+			v.numNodes, tree.numInternalNodes)
+	}
+	if v.numRefs != tree.numExternalRefs {
+		return errors.Errorf("Reached %d of %d refs from the root", v.numRefs, tree.numExternalRefs)
+	}
+	if v.totalStringSize != tree.totalStringSize {
+		return errors.Errorf("The keys have %d bytes, but totalStringSize is %d",
+			v.totalStringSize, tree.totalStringSize)
+	}
+
+	// The free lists must hold every slot that isn't reachable
+	numFree := 0
+	for nodeNum := tree.firstDeletedNode; nodeNum != kNilNode; nodeNum = tree.internalNodes[nodeNum].child[1] {
+		if uint64(nodeNum) >= uint64(len(tree.internalNodes)) {
+			return errors.Errorf("Free node list has out-of-range node %d", nodeNum)
+		}
+		if v.reachedNodes[nodeNum] {
+			return errors.Errorf("Node %d is on the free list, but is live, or the list has a cycle", nodeNum)
+		}
+		v.reachedNodes[nodeNum] = true
+		numFree++
+	}
+	if tree.numInternalNodes+numFree != len(tree.internalNodes) {
+		return errors.Errorf("%d live and %d free internal nodes, but %d allocated",
+			tree.numInternalNodes, numFree, len(tree.internalNodes))
+	}
+	numFree = 0
+	for refNum := tree.firstDeletedRef; refNum != kNilRef; refNum = tree.externalRefs[refNum].nextDeletedRef {
+		if uint64(refNum) >= uint64(len(tree.externalRefs)) {
+			return errors.Errorf("Free ref list has out-of-range ref %d", refNum)
+		}
+		if v.reachedRefs[refNum] {
+			return errors.Errorf("Ref %d is on the free list, but is live, or the list has a cycle", refNum)
+		}
+		v.reachedRefs[refNum] = true
+		numFree++
+	}
+	if tree.numExternalRefs+numFree != len(tree.externalRefs) {
+		return errors.Errorf("%d live and %d free refs, but %d allocated",
+			tree.numExternalRefs, numFree, len(tree.externalRefs))
+	}
+	return nil
+}
+
+type validator[T any] struct {
+	tree         *Critbit[T]
+	reachedNodes []bool
+	reachedRefs  []bool
+	numNodes     int
+	numRefs      int
+
+	totalStringSize int
+
+	// The internal nodes from the root to the current item, the
+	// direction taken at each, and the smallest key under each, which
+	// is the first key reached under it, or kNilRef until then.
+	path         []nodeIndex
+	directions   []byte
+	leftmostRefs []nodeIndex
+}
+
+func (v *validator[T]) validateItem(itemType byte, itemID nodeIndex) error {
+	tree := v.tree
+	switch itemType {
+	case kChildNil:
+		if tree.numExternalRefs != 0 {
+			return errors.Errorf("Tree with %d refs has no root", tree.numExternalRefs)
+		}
+		return nil
+
+	case kChildExtRef:
+		if uint64(itemID) >= uint64(len(tree.externalRefs)) {
+			return errors.Errorf("Ref %d is out of range", itemID)
+		}
+		if v.reachedRefs[itemID] {
+			return errors.Errorf("Ref %d is reachable twice", itemID)
+		}
+		v.reachedRefs[itemID] = true
+		v.numRefs++
+		v.totalStringSize += len(tree.refOriginalKey(itemID))
+		return v.validateKey(itemID)
+
+	case kChildIntNode:
+		if uint64(itemID) >= uint64(len(tree.internalNodes)) {
+			return errors.Errorf("Node %d is out of range", itemID)
+		}
+		if v.reachedNodes[itemID] {
+			return errors.Errorf("Node %d is reachable twice", itemID)
+		}
+		v.reachedNodes[itemID] = true
+		v.numNodes++
+		node := &tree.internalNodes[itemID]
+		if isEndOfKey(node.bit) && node.bit != endOfKeyBit(bitIndex(node.bit)) {
+			return errors.Errorf("Node %d has neither one bit set nor an end of key: 0x%02x",
+				itemID, node.bit)
+		}
+		if len(v.path) > 0 {
+			parent := &tree.internalNodes[v.path[len(v.path)-1]]
+			if node.offset < parent.offset || node.offset == parent.offset &&
+				bitRank(node.bit) >= bitRank(parent.bit) {
+				return errors.Errorf("Node %d (offset %d, bit 0x%02x) does not come after its parent %d (offset %d, bit 0x%02x)",
+					itemID, node.offset, node.bit, v.path[len(v.path)-1], parent.offset, parent.bit)
+			}
+		}
+		for direction := byte(0); direction < 2; direction++ {
+			switch node.getChildType(direction) {
+			case kChildIntNode, kChildExtRef:
+			default:
+				return errors.Errorf("Node %d has child %d of type %d",
+					itemID, direction, node.getChildType(direction))
+			}
+		}
+
+		v.path = append(v.path, itemID)
+		v.leftmostRefs = append(v.leftmostRefs, kNilRef)
+		v.directions = append(v.directions, 0)
+		for direction := byte(0); direction < 2; direction++ {
+			v.directions[len(v.directions)-1] = direction
+			err := v.validateItem(node.getChildType(direction), node.child[direction])
+			if err != nil {
+				return err
+			}
+		}
+		v.path = v.path[:len(v.path)-1]
+		v.leftmostRefs = v.leftmostRefs[:len(v.leftmostRefs)-1]
+		v.directions = v.directions[:len(v.directions)-1]
+		return v.validateAugments(itemID)
+	}
+	return errors.Errorf("Item %d has type %d", itemID, itemType)
+}
+
+// Checks that a key is on the right side of each of its ancestors, and
+// differs from the other keys under each ancestor no sooner than the
+// ancestor's critical bit.
+func (v *validator[T]) validateKey(refNum nodeIndex) error {
+	tree := v.tree
+	key := tree.refKey(refNum)
+	keyBits := tree.refBits(refNum, key)
+	if padding := bitLen(key) - keyBits; padding != 0 {
+		if padding > 7 || len(key) == 0 || key[len(key)-1]&(1<<padding-1) != 0 {
+			return errors.Errorf("Ref %d key %q has invalid padding of %d bits", refNum, key, padding)
+		}
+	}
+	for i, nodeNum := range v.path {
+		node := &tree.internalNodes[nodeNum]
+		// A key must have the bit which a node tests, but may end where
+		// an end-of-key node marks
+		if keyBits < node.position() || keyBits == node.position() && !isEndOfKey(node.bit) {
+			return errors.Errorf("Ref %d key %q is too short for node %d at offset %d",
+				refNum, key, nodeNum, node.offset)
+		}
+		if node.direction(key, keyBits) != v.directions[i] {
+			return errors.Errorf("Ref %d key %q is on side %d of node %d, but its bits say %d",
+				refNum, key, v.directions[i], nodeNum, node.direction(key, keyBits))
+		}
+		if v.leftmostRefs[i] == kNilRef {
+			v.leftmostRefs[i] = refNum
+			continue
+		}
+		leftmost := tree.refKey(v.leftmostRefs[i])
+		identical, off, bit, _ := findCriticalBit(leftmost, tree.refBits(v.leftmostRefs[i], leftmost), key, keyBits)
+		if identical {
+			return errors.Errorf("Ref %d key %q is also in ref %d", refNum, key, v.leftmostRefs[i])
+		}
+		if off < node.offset || off == node.offset && bitRank(bit) > bitRank(node.bit) {
+			return errors.Errorf("Ref %d key %q differs from %q before the critical bit of node %d",
+				refNum, key, leftmost, nodeNum)
+		}
+	}
+	return nil
+}
+
+// Checks the summaries of a node, whose children have been checked
+func (v *validator[T]) validateAugments(nodeNum nodeIndex) error {
+	tree := v.tree
+	node := &tree.internalNodes[nodeNum]
+	if tree.scores != nil {
+		expected := max(tree.childMaxScore(node, kDirectionLeft), tree.childMaxScore(node, kDirectionRight))
+		if tree.scores.nodeMaxScores[nodeNum] != expected {
+			return errors.Errorf("Node %d has maximum score %v, not %v",
+				nodeNum, tree.scores.nodeMaxScores[nodeNum], expected)
+		}
+	}
+	if tree.subtreeSizes != nil {
+		expected := tree.childSubtreeSize(node, kDirectionLeft) + tree.childSubtreeSize(node, kDirectionRight)
+		if tree.subtreeSizes[nodeNum] != expected {
+			return errors.Errorf("Node %d has subtree size %d, not %d",
+				nodeNum, tree.subtreeSizes[nodeNum], expected)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package critbit
+
+import (
+	"math/rand"
+
+	// Bring the symbols in check.v1 into this namespace
+	. "gopkg.in/check.v1"
+)
+
+func newValidateTestTree(c *C) *Critbit[int] {
+	tree := New[int](0)
+	for i, key := range []string{"a", "ab", "abc", "b", "ba", "c"} {
+		_, err := tree.Insert(key, i)
+		c.Assert(err, IsNil)
+	}
+	c.Assert(tree.Validate(), IsNil)
+	return tree
+}
+
+func (s *MySuite) TestValidate(c *C) {
+	rng := rand.New(rand.NewSource(41))
+	trees := []*Critbit[int]{
+		New[int](0),
+		New[int](0, WithKeyArena(0)),
+		New[int](0, WithCollation(FoldASCII)),
+		New[int](0, WithSubtreeSizes()),
+	}
+	for _, tree := range trees {
+		c.Check(tree.Validate(), IsNil)
+		for i := 0; i < 1000; i++ {
+			key := randomKey(rng)
+			if rng.Intn(3) == 0 {
+				tree.Delete(key)
+			} else {
+				tree.Insert(key, i)
+			}
+			if i == 500 {
+				_, err := tree.SetScore(key, 5)
+				c.Assert(err, IsNil)
+				tree.RandomKey(rng)
+			}
+		}
+		c.Check(tree.Validate(), IsNil)
+		left, right := tree.SplitAt(tree.Length() / 3)
+		c.Check(left.Validate(), IsNil)
+		c.Check(right.Validate(), IsNil)
+	}
+}
+
+func (s *MySuite) TestValidateFindsCorruption(c *C) {
+	corruptions := []struct {
+		name     string
+		corrupt  func(tree *Critbit[int])
+		expected string
+	}{
+		{"nil child", func(tree *Critbit[int]) {
+			node := &tree.internalNodes[tree.rootItem]
+			node.setChild(kDirectionRight, 0, kChildNil)
+			// Keep the counts consistent, so the walk gets to the nil child
+			tree.numInternalNodes = 3
+			tree.numExternalRefs = 4
+		}, "Node [0-9]+ has child 1 of type 0"},
+		{"swapped children", func(tree *Critbit[int]) {
+			node := &tree.internalNodes[tree.rootItem]
+			leftType, rightType := node.getChildType(kDirectionLeft), node.getChildType(kDirectionRight)
+			leftID, rightID := node.child[kDirectionLeft], node.child[kDirectionRight]
+			node.setChild(kDirectionLeft, rightID, rightType)
+			node.setChild(kDirectionRight, leftID, leftType)
+		}, `Ref [0-9]+ key ".*" is on side 0 of node [0-9]+, but its bits say 1`},
+		{"bit order", func(tree *Critbit[int]) {
+			node := &tree.internalNodes[tree.rootItem]
+			node.offset = 1
+		}, "Node [0-9]+ .* does not come after its parent .*"},
+		{"two bits", func(tree *Critbit[int]) {
+			tree.internalNodes[tree.rootItem].bit = 0x05
+		}, "Node [0-9]+ has neither one bit set nor an end of key: 0x05"},
+		{"node count", func(tree *Critbit[int]) {
+			tree.numInternalNodes++
+		}, "Tree has 6 refs but 6 internal nodes"},
+		{"reachable twice", func(tree *Critbit[int]) {
+			node := &tree.internalNodes[tree.rootItem]
+			node.setChild(kDirectionRight, node.child[kDirectionLeft], node.getChildType(kDirectionLeft))
+		}, "(Node|Ref) [0-9]+ is reachable twice"},
+		{"free list cycle", func(tree *Critbit[int]) {
+			tree.Delete("c")
+			tree.Delete("b")
+			tree.internalNodes[tree.firstDeletedNode].child[1] = tree.firstDeletedNode
+		}, "Node [0-9]+ is on the free list, but is live, or the list has a cycle"},
+		{"live ref on free list", func(tree *Critbit[int]) {
+			tree.externalRefs[tree.leftmostRef(kChildIntNode, tree.rootItem)].nextDeletedRef = kNilRef
+			tree.firstDeletedRef = tree.leftmostRef(kChildIntNode, tree.rootItem)
+		}, "Ref [0-9]+ is on the free list, but is live, or the list has a cycle"},
+		{"max score", func(tree *Critbit[int]) {
+			_, _ = tree.SetScore("ab", 3)
+			tree.scores.refScores[tree.leftmostRef(kChildIntNode, tree.rootItem)] = 10
+		}, "Node [0-9]+ has maximum score 3, not 10"},
+		{"subtree size", func(tree *Critbit[int]) {
+			tree.subtreeSizes = make([]int, len(tree.internalNodes))
+			tree.refreshAllAugments(tree.rootItemType(), tree.rootItem)
+			tree.subtreeSizes[tree.rootItem]++
+		}, "Node [0-9]+ has subtree size 7, not 6"},
+	}
+	for _, corruption := range corruptions {
+		tree := newValidateTestTree(c)
+		corruption.corrupt(tree)
+		c.Check(tree.Validate(), ErrorMatches, corruption.expected, Commentf(corruption.name))
+	}
+}